@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// colors of badge like on shields.io
+const (
+	badgeColorRed    = "#e05d44"
+	badgeColorYellow = "#dfb317"
+	badgeColorGreen  = "#4c1"
+)
+
+var badgeTemplate = template.Must(template.New("badge").Parse(
+	`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" aria-label="{{.Label}}: {{.Value}}">` +
+		`<title>{{.Label}}: {{.Value}}</title>` +
+		`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>` +
+		`<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>` +
+		`<g clip-path="url(#r)"><rect width="{{.LabelWidth}}" height="20" fill="#555"/><rect x="{{.LabelWidth}}" width="{{.ValueWidth}}" height="20" fill="{{.Color}}"/><rect width="{{.Width}}" height="20" fill="url(#s)"/></g>` +
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">` +
+		`<text x="{{.LabelX}}" y="15" fill="#010101" fill-opacity=".3">{{.Label}}</text><text x="{{.LabelX}}" y="14">{{.Label}}</text>` +
+		`<text x="{{.ValueX}}" y="15" fill="#010101" fill-opacity=".3">{{.Value}}</text><text x="{{.ValueX}}" y="14">{{.Value}}</text>` +
+		`</g></svg>` + "\n",
+))
+
+// badgeThresholds - coverage below "yellow" is red, below "green" is yellow, other is green
+type badgeThresholds struct {
+	yellow, green float64
+}
+
+func parseBadgeThresholds(raw string) (result badgeThresholds, err error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) != 2 {
+		return result, fmt.Errorf("badge thresholds %q: expected two comma-separated numbers", raw)
+	}
+
+	if result.yellow, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64); err != nil {
+		return result, fmt.Errorf("badge thresholds %q parse failed: %s", raw, err)
+	}
+	if result.green, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err != nil {
+		return result, fmt.Errorf("badge thresholds %q parse failed: %s", raw, err)
+	}
+	if result.yellow > result.green {
+		return result, fmt.Errorf("badge thresholds %q: first threshold is greater than second", raw)
+	}
+
+	return result, nil
+}
+
+func (thresholds badgeThresholds) getColor(stat float64) string {
+	switch {
+	case stat < thresholds.yellow:
+		return badgeColorRed
+	case stat < thresholds.green:
+		return badgeColorYellow
+	default:
+		return badgeColorGreen
+	}
+}
+
+// getBadgeTextWidth - approximate width of text in Verdana 11px
+func getBadgeTextWidth(text string) int {
+	return len([]rune(text))*7 + 10
+}
+
+// getBadge - get shields-style SVG badge with total coverage
+func getBadge(stat float64, thresholds badgeThresholds) ([]byte, error) {
+	label, value := "coverage", fmt.Sprintf("%.1f%%", stat)
+	labelWidth, valueWidth := getBadgeTextWidth(label), getBadgeTextWidth(value)
+
+	result := &bytes.Buffer{}
+	err := badgeTemplate.Execute(result, map[string]interface{}{
+		"Label":      label,
+		"Value":      value,
+		"Color":      thresholds.getColor(stat),
+		"Width":      labelWidth + valueWidth,
+		"LabelWidth": labelWidth,
+		"ValueWidth": valueWidth,
+		"LabelX":     float64(labelWidth) / 2,
+		"ValueX":     float64(labelWidth) + float64(valueWidth)/2,
+	})
+
+	return result.Bytes(), err
+}
+
+func writeBadge(fileName string, stat float64, thresholds badgeThresholds) error {
+	badge, err := getBadge(stat, thresholds)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(fileName, badge, 0o644) // #nosec
+}
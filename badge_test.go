@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_parseBadgeThresholds(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    badgeThresholds
+		wantErr bool
+	}{
+		{raw: "50,80", want: badgeThresholds{yellow: 50, green: 80}},
+		{raw: " 60.5 , 90 ", want: badgeThresholds{yellow: 60.5, green: 90}},
+		{raw: "50", wantErr: true},
+		{raw: "50,80,90", wantErr: true},
+		{raw: "a,80", wantErr: true},
+		{raw: "50,b", wantErr: true},
+		{raw: "80,50", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseBadgeThresholds(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseBadgeThresholds() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseBadgeThresholds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getBadge(t *testing.T) {
+	thresholds := badgeThresholds{yellow: 50, green: 80}
+
+	for stat, color := range map[float64]string{0: badgeColorRed, 49.9: badgeColorRed, 50: badgeColorYellow, 80: badgeColorGreen, 100: badgeColorGreen} {
+		badge, err := getBadge(stat, thresholds)
+		if err != nil {
+			t.Fatalf("getBadge() error: %s", err)
+		}
+		if !strings.Contains(string(badge), `fill="`+color+`"`) {
+			t.Errorf("getBadge(%v) without color %s:\n%s", stat, color, badge)
+		}
+	}
+
+	badge, err := getBadge(75.25, thresholds)
+	if err != nil {
+		t.Fatalf("getBadge() error: %s", err)
+	}
+	if !strings.HasPrefix(string(badge), `<svg xmlns="http://www.w3.org/2000/svg" width="111" height="20" role="img" aria-label="coverage: 75.2%">`) {
+		t.Errorf("getBadge() failed:\n%s", badge)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "badge.svg")
+	if err := writeBadge(fileName, 75.25, thresholds); err != nil {
+		t.Fatalf("writeBadge() error: %s", err)
+	}
+	written, err := os.ReadFile(fileName)
+	if err != nil || string(written) != string(badge) {
+		t.Errorf("writeBadge() failed: %v", err)
+	}
+}
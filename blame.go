@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/msoap/go-carpet/carpet"
+)
+
+// blameCommit - commit which last touched the line
+type blameCommit struct {
+	hash   string
+	author string
+}
+
+// blameFunc - get commits of lines in ranges of file by line number
+type blameFunc func(fileName string, ranges []carpet.LineRange) (map[int]blameCommit, error)
+
+// authorStat - not covered code by one author
+type authorStat struct {
+	author     string
+	statements int
+	lines      int
+	commits    map[string]struct{}
+}
+
+// getGitBlame - run "git blame" for ranges of lines of file
+func getGitBlame(fileName string, ranges []carpet.LineRange) (map[int]blameCommit, error) {
+	args := []string{"-C", filepath.Dir(fileName), "blame", "--porcelain"}
+	for _, lineRange := range ranges {
+		args = append(args, "-L", fmt.Sprintf("%d,%d", lineRange.Begin, lineRange.End))
+	}
+	args = append(args, "--", filepath.Base(fileName))
+
+	out, err := exec.Command("git", args...).Output() // #nosec
+	if err != nil {
+		return nil, fmt.Errorf("git blame %s: %w", fileName, err)
+	}
+
+	return parseGitBlame(out)
+}
+
+// parseGitBlame - commits by line number (in current file) from output of "git blame --porcelain",
+// headers of commit are written only for the first line of each commit
+func parseGitBlame(out []byte) (map[int]blameCommit, error) {
+	result := map[int]blameCommit{}
+	authors := map[string]string{}
+	hash, line := "", 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			// content of line ends the entry
+			result[line] = blameCommit{hash: hash, author: authors[hash]}
+		case strings.HasPrefix(text, "author "):
+			authors[hash] = strings.TrimPrefix(text, "author ")
+		default:
+			fields := strings.Fields(text)
+			if len(fields) < 3 || len(fields[0]) != 40 {
+				continue
+			}
+
+			finalLine, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse git blame line: %q", text)
+			}
+			hash, line = fields[0], finalLine
+		}
+	}
+
+	return result, scanner.Err()
+}
+
+// getBlameStats - authors of not covered code sorted by not covered statements,
+// statements of block are attributed to the author of the most lines of block
+func getBlameStats(files []carpet.FileCover, blame blameFunc) []authorStat {
+	stats := map[string]*authorStat{}
+	getStat := func(author string) *authorStat {
+		if _, ok := stats[author]; !ok {
+			stats[author] = &authorStat{author: author, commits: map[string]struct{}{}}
+		}
+		return stats[author]
+	}
+
+	for _, file := range files {
+		ranges := carpet.UncoveredLineRanges(file.Profile.Blocks)
+		if len(ranges) == 0 {
+			continue
+		}
+
+		commits, err := blame(file.FileName, ranges)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		uncoveredLines := map[int]bool{}
+		for _, line := range carpet.GetLineStats(file) {
+			if !line.Covered {
+				uncoveredLines[line.Line] = true
+				if commit, ok := commits[line.Line]; ok {
+					stat := getStat(commit.author)
+					stat.lines++
+					stat.commits[commit.hash] = struct{}{}
+				}
+			}
+		}
+
+		for _, block := range file.Profile.Blocks {
+			if block.Count > 0 || block.NumStmt == 0 {
+				continue
+			}
+
+			// lines with code, or all lines of block if it has only braces
+			linesByAuthor, allLinesByAuthor := map[string]int{}, map[string]int{}
+			for line := block.StartLine; line <= block.EndLine; line++ {
+				if commit, ok := commits[line]; ok {
+					allLinesByAuthor[commit.author]++
+					if uncoveredLines[line] {
+						linesByAuthor[commit.author]++
+					}
+				}
+			}
+			if len(linesByAuthor) == 0 {
+				linesByAuthor = allLinesByAuthor
+			}
+
+			if author := getTopAuthor(linesByAuthor); author != "" {
+				getStat(author).statements += block.NumStmt
+			}
+		}
+	}
+
+	result := make([]authorStat, 0, len(stats))
+	for _, stat := range stats {
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].statements != result[j].statements {
+			return result[i].statements > result[j].statements
+		}
+		return result[i].author < result[j].author
+	})
+
+	return result
+}
+
+// getTopAuthor - author with the most lines, the first by name for equal lines
+func getTopAuthor(linesByAuthor map[string]int) (result string) {
+	for author, lines := range linesByAuthor {
+		if lines > linesByAuthor[result] || (lines == linesByAuthor[result] && (result == "" || author < result)) {
+			result = author
+		}
+	}
+
+	return result
+}
+
+// getBlameReport - top-N authors of not covered code by git blame
+func getBlameReport(files []carpet.FileCover, limit int, blame blameFunc, config Config) string {
+	stats := getBlameStats(files, blame)
+	if len(stats) > limit {
+		stats = stats[:limit]
+	}
+	if len(stats) == 0 {
+		return ""
+	}
+
+	authorWidth := len("Author")
+	for _, stat := range stats {
+		if len(stat.author) > authorWidth {
+			authorWidth = len(stat.author)
+		}
+	}
+
+	header := fmt.Sprintf("%-*s %10s %6s %7s", authorWidth, "Author", "statements", "lines", "commits")
+	result := "\n" + getColorHeader("Not covered code by authors:", false, config) + getColorHeader(header, true, config)
+	for _, stat := range stats {
+		result += fmt.Sprintf("%-*s %10d %6d %7d\n", authorWidth, stat.author, stat.statements, stat.lines, len(stat.commits))
+	}
+
+	return result
+}
+
+// getMarkdownBlame - table with authors of not covered code
+func getMarkdownBlame(files []carpet.FileCover, limit int, blame blameFunc) string {
+	result := &bytes.Buffer{}
+	result.WriteString("## Not covered code by authors\n\n| Author | Statements | Lines | Commits |\n|---|---:|---:|---:|\n")
+	for i, stat := range getBlameStats(files, blame) {
+		if i >= limit {
+			break
+		}
+		fmt.Fprintf(result, "| %s | %d | %d | %d |\n", strings.ReplaceAll(stat.author, "|", "\\|"), stat.statements, stat.lines, len(stat.commits))
+	}
+
+	return result.String()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+func Test_parseGitBlame(t *testing.T) {
+	hashA, hashB := strings.Repeat("a", 40), strings.Repeat("b", 40)
+	out := hashA + " 4 4 2\n" +
+		"author Alice\n" +
+		"author-mail <alice@example.com>\n" +
+		"summary Add a\n" +
+		"filename a.go\n" +
+		"\tif x > 0 {\n" +
+		hashA + " 5 5\n" +
+		"\t\treturn 1\n" +
+		hashB + " 3 7 1\n" +
+		"author Bob\n" +
+		"previous " + hashA + " a.go\n" +
+		"filename a.go\n" +
+		"\treturn 0\n"
+
+	got, err := parseGitBlame([]byte(out))
+	want := map[int]blameCommit{
+		4: {hash: hashA, author: "Alice"},
+		5: {hash: hashA, author: "Alice"},
+		7: {hash: hashB, author: "Bob"},
+	}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("parseGitBlame() = %v, %v, want %v", got, err, want)
+	}
+}
+
+func Test_getBlameReport(t *testing.T) {
+	files := []carpet.FileCover{{
+		Profile: &cover.Profile{FileName: "pkg/a.go", Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 19, EndLine: 4, EndCol: 11, NumStmt: 1, Count: 1},
+			{StartLine: 4, StartCol: 11, EndLine: 7, EndCol: 3, NumStmt: 2, Count: 0},
+			{StartLine: 8, StartCol: 2, EndLine: 8, EndCol: 10, NumStmt: 1, Count: 0},
+		}},
+		FileName: "/src/pkg/a.go",
+		Content:  []byte("package pkg\n\nfunc a(x int) int {\n\tif x > 0 {\n\t\tx++\n\t\treturn x\n\t}\n\treturn 0\n}\n"),
+	}}
+
+	blame := func(fileName string, ranges []carpet.LineRange) (map[int]blameCommit, error) {
+		if fileName != "/src/pkg/a.go" || !reflect.DeepEqual(ranges, []carpet.LineRange{{Begin: 4, End: 8}}) {
+			t.Errorf("blame(%q, %v): unexpected arguments", fileName, ranges)
+		}
+		return map[int]blameCommit{
+			4: {hash: "1", author: "Alice"},
+			5: {hash: "2", author: "Bob"},
+			6: {hash: "2", author: "Bob"},
+			7: {hash: "1", author: "Alice"},
+			8: {hash: "1", author: "Alice"},
+		}, nil
+	}
+
+	config := Config{plain: true}
+	got := getBlameReport(files, 10, blame, config)
+	want := "\n" + getColorHeader("Not covered code by authors:", false, config) +
+		getColorHeader("Author statements  lines commits", true, config) +
+		"Bob             2      2       1\n" +
+		"Alice           1      1       1\n"
+	if got != want {
+		t.Errorf("getBlameReport():\ngot : %q\nwant: %q", got, want)
+	}
+
+	if got := getBlameReport(files, 0, blame, config); got != "" {
+		t.Errorf("getBlameReport() with limit 0 = %q", got)
+	}
+}
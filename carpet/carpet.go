@@ -0,0 +1,250 @@
+/*
+Package carpet - library for read Go cover profiles, resolve source files and get coverage of files and functions.
+
+It is used by go-carpet command, and may be used in other tools instead of parsing of go-carpet output:
+
+	files, err := carpet.LoadFiles("cover.out", carpet.Filter{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, stat := range carpet.GetFileStats(files) {
+		fmt.Printf("%s: %.1f%%\n", stat.FileName, stat.Coverage)
+	}
+*/
+package carpet
+
+import (
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+var (
+	reWindowsPathFix = regexp.MustCompile(`^_\\([A-Z])_`)
+
+	errIsNotInGoMod = fmt.Errorf("is not in go modules")
+)
+
+// FileCover - coverage profile of one source file
+type FileCover struct {
+	Profile    *cover.Profile
+	FileName   string // resolved path to source file
+	Content    []byte
+	Dependency string // "module@version" for third-party packages, "std" for standard library, empty for own files
+}
+
+// Coverage - coverage of file in percent
+func (file FileCover) Coverage() float64 {
+	return Coverage(file.Profile.Blocks)
+}
+
+// Filter - which files from cover profile are loaded
+type Filter struct {
+	// substrings of file names, empty for all files
+	Files []string
+	// skip files which covered more than MinCoverage percent, 0 or 100 for all files
+	MinCoverage float64
+	// directory in which import paths of packages are resolved, empty for current directory
+	Dir string
+}
+
+// IsSkipped - file is skipped by minimal coverage
+func (filter Filter) IsSkipped(fileProfileBlocks []cover.ProfileBlock) bool {
+	return filter.MinCoverage > 0 && filter.MinCoverage < 100.0 && Coverage(fileProfileBlocks) > filter.MinCoverage
+}
+
+func (filter Filter) isFileMatched(fileName string) bool {
+	if len(filter.Files) == 0 {
+		return true
+	}
+
+	for _, file := range filter.Files {
+		if strings.Contains(fileName, file) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LoadFiles - parse cover profile and read all source files from it
+func LoadFiles(coverFileName string, filter Filter) (result []FileCover, err error) {
+	coverProfile, err := cover.ParseProfiles(coverFileName)
+	if err != nil {
+		return result, err
+	}
+
+	profileFileNames := make([]string, 0, len(coverProfile))
+	for _, fileProfile := range coverProfile {
+		profileFileNames = append(profileFileNames, fileProfile.FileName)
+	}
+	// resolve all packages with one "go list" call, on error fallback to guess paths, error is returned if it is failed too
+	resolver := newPkgResolver(filter.Dir)
+	_ = resolver.preload(profileFileNames)
+
+	for _, fileProfile := range coverProfile {
+		if filter.IsSkipped(fileProfile.Blocks) {
+			continue
+		}
+
+		fileName, err := resolver.absFileName(fileProfile.FileName)
+		if err != nil {
+			if resolver.err != nil {
+				err = fmt.Errorf("%s (%s)", err, resolver.err)
+			}
+			return result, err
+		}
+
+		if !filter.isFileMatched(fileName) {
+			continue
+		}
+
+		fileBytes, err := os.ReadFile(fileName) // #nosec
+		if err != nil {
+			return result, err
+		}
+
+		dependency := ""
+		if pkg, ok := resolver.getPackage(fileProfile.FileName); ok {
+			dependency = pkg.getDependency()
+		}
+
+		result = append(result, FileCover{
+			Profile:    fileProfile,
+			FileName:   fileName,
+			Content:    fileBytes,
+			Dependency: dependency,
+		})
+	}
+
+	return result, nil
+}
+
+// isLocalProfileFileName - file name in cover profile is a path in file system, not an import path
+func isLocalProfileFileName(profileFileName string) bool {
+	return strings.HasPrefix(profileFileName, "/") || strings.HasPrefix(profileFileName, "_")
+}
+
+// AbsFileName - get absolute path of source file by file name from cover profile, import path is resolved in current directory
+func AbsFileName(profileFileName string) (string, error) {
+	return newPkgResolver("").absFileName(profileFileName)
+}
+
+func (r *pkgResolver) absFileName(profileFileName string) (string, error) {
+	if strings.HasPrefix(profileFileName, "/") {
+		// TODO: what about windows?
+		return profileFileName, nil
+	}
+
+	if strings.HasPrefix(profileFileName, "_") {
+		// absolute path (or relative in tests)
+		if runtime.GOOS != "windows" {
+			return strings.TrimLeft(profileFileName, "_"), nil
+		}
+		// "_\C_\Users\..." -> "C:\Users\..."
+		return reWindowsPathFix.ReplaceAllString(profileFileName, "$1:"), nil
+	}
+
+	// import path, resolve it via "go list"
+	if fileName, ok := r.getFileName(profileFileName); ok {
+		return fileName, nil
+	}
+
+	if fileName, err := guessAbsPathInGoMod(r.getGoModFilename(), profileFileName); err != errIsNotInGoMod {
+		return fileName, err
+	}
+
+	// file in one dir in GOPATH
+	return guessAbsPathInGOPATH(os.Getenv("GOPATH"), profileFileName)
+}
+
+func guessAbsPathInGOPATH(GOPATH, relPath string) (absPath string, err error) {
+	if GOPATH == "" {
+		GOPATH = build.Default.GOPATH
+		if GOPATH == "" {
+			return "", fmt.Errorf("GOPATH is not set")
+		}
+	}
+
+	gopathChunks := strings.Split(GOPATH, string(os.PathListSeparator))
+	for _, gopathChunk := range gopathChunks {
+		guessAbsPath := filepath.Join(gopathChunk, "src", relPath)
+		if _, err = os.Stat(guessAbsPath); err == nil {
+			absPath = guessAbsPath
+			break
+		}
+	}
+
+	if absPath == "" {
+		return "", fmt.Errorf("file '%s' not found in GOPATH", relPath)
+	}
+
+	return absPath, err
+}
+
+// Merged - files merged from several test runs
+type Merged struct {
+	Files []FileCover
+	index map[string]int
+}
+
+// Add - add files, counters of the same file from different runs are merged
+func (merged *Merged) Add(files ...FileCover) {
+	if merged.index == nil {
+		merged.index = map[string]int{}
+	}
+
+	for _, file := range files {
+		i, ok := merged.index[file.FileName]
+		if !ok {
+			merged.index[file.FileName] = len(merged.Files)
+			merged.Files = append(merged.Files, file)
+			continue
+		}
+
+		mergedProfile := *merged.Files[i].Profile
+		mergedProfile.Blocks = MergeProfileBlocks(mergedProfile.Blocks, file.Profile.Blocks)
+		merged.Files[i].Profile = &mergedProfile
+	}
+}
+
+// BlockPos - position of block in source file
+type BlockPos struct{ StartLine, StartCol, EndLine, EndCol int }
+
+// GetBlockPos - get position of profile block
+func GetBlockPos(block cover.ProfileBlock) BlockPos {
+	return BlockPos{block.StartLine, block.StartCol, block.EndLine, block.EndCol}
+}
+
+// MergeProfileBlocks - merge counters of blocks of one file from two profiles
+func MergeProfileBlocks(dst, src []cover.ProfileBlock) []cover.ProfileBlock {
+	result := append([]cover.ProfileBlock{}, dst...)
+	index := make(map[BlockPos]int, len(result))
+	for i, block := range result {
+		index[GetBlockPos(block)] = i
+	}
+
+	for _, block := range src {
+		if i, ok := index[GetBlockPos(block)]; ok {
+			result[i].Count += block.Count
+			continue
+		}
+		result = append(result, block)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].StartLine != result[j].StartLine {
+			return result[i].StartLine < result[j].StartLine
+		}
+		return result[i].StartCol < result[j].StartCol
+	})
+
+	return result
+}
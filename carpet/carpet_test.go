@@ -0,0 +1,116 @@
+package carpet
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func Test_guessAbsPathInGOPATH(t *testing.T) {
+	GOPATH := ""
+	absPath, err := guessAbsPathInGOPATH(GOPATH, "file.golang")
+	if absPath != "" || err == nil {
+		t.Errorf("1. guessAbsPathInGOPATH() empty GOPATH")
+	}
+
+	cwd, _ := os.Getwd()
+
+	GOPATH = filepath.Join(cwd, "..", "testdata")
+	absPath, err = guessAbsPathInGOPATH(GOPATH, "file.golang")
+	if err != nil {
+		t.Errorf("2. guessAbsPathInGOPATH() error: %s", err)
+	}
+	if absPath != filepath.Join(cwd, "..", "testdata", "src", "file.golang") {
+		t.Errorf("3. guessAbsPathInGOPATH() empty GOPATH")
+	}
+
+	GOPATH = filepath.Join(cwd, "..", "testdata") + string(os.PathListSeparator) + "/tmp"
+	absPath, err = guessAbsPathInGOPATH(GOPATH, "file.golang")
+	if err != nil {
+		t.Errorf("4. guessAbsPathInGOPATH() error: %s", err)
+	}
+	if absPath != filepath.Join(cwd, "..", "testdata", "src", "file.golang") {
+		t.Errorf("5. guessAbsPathInGOPATH() empty GOPATH")
+	}
+
+	GOPATH = "/tmp" + string(os.PathListSeparator) + "/"
+	absPath, err = guessAbsPathInGOPATH(GOPATH, "file.golang")
+	if absPath != "" || err == nil {
+		t.Errorf("6. guessAbsPathInGOPATH() file not in GOPATH")
+	}
+}
+
+func Test_LoadFiles(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	coverFileName := filepath.Join(t.TempDir(), "cover.out")
+	profile := "mode: set\ngithub.com/msoap/go-carpet/carpet/stat.go:1.1,2.1 1 1\n"
+	if err := os.WriteFile(coverFileName, []byte(profile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("dir", func(t *testing.T) {
+		files, err := LoadFiles(coverFileName, Filter{Dir: ".."})
+		if err != nil {
+			t.Fatalf("LoadFiles() error: %s", err)
+		}
+		if len(files) != 1 || files[0].FileName != filepath.Join(cwd, "stat.go") {
+			t.Errorf("LoadFiles() got %v", files)
+		}
+	})
+
+	t.Run("dir is not in module", func(t *testing.T) {
+		_, err := LoadFiles(coverFileName, Filter{Dir: t.TempDir()})
+		if err == nil {
+			t.Errorf("LoadFiles() resolved file out of directory of module")
+		}
+	})
+}
+
+func Test_Merged_Add(t *testing.T) {
+	deps := Merged{}
+	deps.Add(
+		FileCover{
+			FileName:   "/mod/dep@v1.0.0/file.go",
+			Dependency: "dep@v1.0.0",
+			Profile: &cover.Profile{FileName: "dep/file.go", Mode: "count", Blocks: []cover.ProfileBlock{
+				{StartLine: 3, StartCol: 1, EndLine: 3, EndCol: 10, NumStmt: 1, Count: 0},
+				{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 10, NumStmt: 1, Count: 2},
+			}},
+		},
+	)
+	deps.Add(
+		FileCover{
+			FileName:   "/mod/dep@v1.0.0/file.go",
+			Dependency: "dep@v1.0.0",
+			Profile: &cover.Profile{FileName: "dep/file.go", Mode: "count", Blocks: []cover.ProfileBlock{
+				{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 10, NumStmt: 1, Count: 1},
+				{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 10, NumStmt: 1, Count: 0},
+				{StartLine: 3, StartCol: 1, EndLine: 3, EndCol: 10, NumStmt: 1, Count: 5},
+			}},
+		},
+		FileCover{
+			FileName:   "/mod/dep@v1.0.0/other.go",
+			Dependency: "dep@v1.0.0",
+			Profile:    &cover.Profile{FileName: "dep/other.go", Mode: "count"},
+		},
+	)
+
+	if len(deps.Files) != 2 {
+		t.Fatalf("Add() got %d files, want 2", len(deps.Files))
+	}
+
+	expectBlocks := []cover.ProfileBlock{
+		{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 10, NumStmt: 1, Count: 3},
+		{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 10, NumStmt: 1, Count: 0},
+		{StartLine: 3, StartCol: 1, EndLine: 3, EndCol: 10, NumStmt: 1, Count: 5},
+	}
+	if !reflect.DeepEqual(deps.Files[0].Profile.Blocks, expectBlocks) {
+		t.Errorf("Add() merged blocks:\ngot : %v\nwant: %v", deps.Files[0].Profile.Blocks, expectBlocks)
+	}
+}
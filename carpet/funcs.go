@@ -0,0 +1,125 @@
+package carpet
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// Func - one go function in source
+type Func struct {
+	Name      string `json:"name"`
+	Begin     int    `json:"-"`
+	End       int    `json:"-"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	// cyclomatic complexity: 1 + number of branches (if, for, case, &&, ||)
+	Complexity int `json:"complexity"`
+}
+
+// GetGolangFuncs - parse golang source file and get all functions
+//
+//	funcs, err := GetGolangFuncs(goFileContentInBytes)
+func GetGolangFuncs(fileContent []byte) (result []Func, err error) {
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, "", fileContent, 0)
+	if err != nil {
+		return result, err
+	}
+
+	ast.Inspect(astFile, func(nodeRaw ast.Node) bool {
+		switch node := nodeRaw.(type) {
+		case *ast.FuncDecl:
+			result = append(result, Func{
+				Name:       node.Name.String(),
+				Begin:      int(node.Pos()),
+				End:        int(node.End()),
+				StartLine:  fset.Position(node.Pos()).Line,
+				EndLine:    fset.Position(node.End()).Line,
+				Complexity: getComplexity(node),
+			})
+		}
+
+		return true
+	})
+
+	return result, nil
+}
+
+// getComplexity - get cyclomatic complexity of function, function literals are counted as part of function
+func getComplexity(funcNode *ast.FuncDecl) int {
+	result := 1
+	ast.Inspect(funcNode, func(nodeRaw ast.Node) bool {
+		switch node := nodeRaw.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			result++
+		case *ast.CaseClause:
+			if node.List != nil {
+				result++
+			}
+		case *ast.CommClause:
+			if node.Comm != nil {
+				result++
+			}
+		case *ast.BinaryExpr:
+			if node.Op == token.LAND || node.Op == token.LOR {
+				result++
+			}
+		}
+
+		return true
+	})
+
+	return result
+}
+
+// FuncStat - coverage of one function with CRAP score (Change Risk Anti-Patterns)
+type FuncStat struct {
+	Func
+	FileName string  `json:"file_name"` // file name from cover profile, without "_" prefix of local files
+	Coverage float64 `json:"coverage"`  // in percent
+	CRAP     float64 `json:"crap"`
+}
+
+// CRAPScore - complexity^2 * (1 - coverage)^3 + complexity, coverage in percent
+func CRAPScore(complexity int, coverage float64) float64 {
+	uncovered := 1 - coverage/100
+	return float64(complexity*complexity)*math.Pow(uncovered, 3) + float64(complexity)
+}
+
+// GetFuncStats - get coverage of each function of files, functions without statements are skipped
+func GetFuncStats(files []FileCover) (result []FuncStat) {
+	for _, file := range files {
+		funcs, err := GetGolangFuncs(file.Content)
+		if err != nil {
+			continue
+		}
+
+		for _, fn := range funcs {
+			blocks := []cover.ProfileBlock{}
+			for _, block := range file.Profile.Blocks {
+				if block.StartLine >= fn.StartLine && block.EndLine <= fn.EndLine {
+					blocks = append(blocks, block)
+				}
+			}
+
+			if len(blocks) == 0 {
+				continue
+			}
+
+			coverage := Coverage(blocks)
+			result = append(result, FuncStat{
+				Func:     fn,
+				FileName: strings.TrimLeft(file.Profile.FileName, "_"),
+				Coverage: coverage,
+				CRAP:     CRAPScore(fn.Complexity, coverage),
+			})
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,150 @@
+package carpet
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+const testGolangSrc = `package somepkg
+
+import "fmt"
+
+type T int
+
+func (r T) String() string {
+	return fmt.Sprintf("%v", r)
+}
+
+func fn() string {
+	return "Hello"
+}
+`
+
+func Test_GetGolangFuncs(t *testing.T) {
+	tests := []struct {
+		name        string
+		fileContent []byte
+		wantResult  []Func
+		wantErr     bool
+	}{
+		{
+			name:        "without error",
+			fileContent: []byte(testGolangSrc),
+			wantResult: []Func{
+				{Name: "String", Begin: 44, End: 103, StartLine: 7, EndLine: 9, Complexity: 1},
+				{Name: "fn", Begin: 105, End: 141, StartLine: 11, EndLine: 13, Complexity: 1},
+			},
+			wantErr: false,
+		},
+		{
+			name:        "with error",
+			fileContent: []byte("..."),
+			wantResult:  nil,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotResult, err := GetGolangFuncs(tt.fileContent)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetGolangFuncs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("GetGolangFuncs() = %#v, want %#v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
+
+func Test_getComplexity(t *testing.T) {
+	src := `package somepkg
+
+func fn(a, b int, ch chan int) int {
+	if a > 0 && b > 0 || a < -10 {
+		return 1
+	}
+	for i := range []int{1, 2} {
+		switch i {
+		case 1, 2:
+		case 3:
+		default:
+		}
+	}
+	select {
+	case <-ch:
+	default:
+	}
+	f := func() bool { return a == b || b == 0 }
+	_ = f
+	return 0
+}
+`
+	funcs, err := GetGolangFuncs([]byte(src))
+	if err != nil || len(funcs) != 1 {
+		t.Fatalf("GetGolangFuncs() = %v, %v", funcs, err)
+	}
+	// 1 + if + && + || + range + 2 case + 1 select case + || in func literal
+	if funcs[0].Complexity != 9 {
+		t.Errorf("getComplexity() = %d, want 9", funcs[0].Complexity)
+	}
+}
+
+func Test_CRAPScore(t *testing.T) {
+	tests := []struct {
+		complexity int
+		coverage   float64
+		want       float64
+	}{
+		{complexity: 1, coverage: 100, want: 1},
+		{complexity: 5, coverage: 0, want: 30},
+		{complexity: 10, coverage: 50, want: 22.5},
+	}
+
+	for _, tt := range tests {
+		if got := CRAPScore(tt.complexity, tt.coverage); got != tt.want {
+			t.Errorf("CRAPScore(%d, %v) = %v, want %v", tt.complexity, tt.coverage, got, tt.want)
+		}
+	}
+}
+
+func Test_GetFuncStats(t *testing.T) {
+	src := `package pkg
+
+func simple() int {
+	return 1
+}
+
+func complex(a int) int {
+	if a > 0 {
+		return 1
+	}
+	return 0
+}
+
+type T struct{}
+`
+	files := []FileCover{{
+		Profile: &cover.Profile{FileName: "_/src/pkg/a.go", Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 19, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 0},
+			{StartLine: 7, StartCol: 25, EndLine: 8, EndCol: 11, NumStmt: 2, Count: 1},
+			{StartLine: 8, StartCol: 11, EndLine: 10, EndCol: 3, NumStmt: 1, Count: 0},
+			{StartLine: 11, StartCol: 2, EndLine: 11, EndCol: 10, NumStmt: 1, Count: 1},
+		}},
+		Content: []byte(src),
+	}}
+
+	got := GetFuncStats(files)
+	if len(got) != 2 {
+		t.Fatalf("GetFuncStats() = %v, want 2 functions", got)
+	}
+	if got[0].Name != "simple" || got[0].FileName != "/src/pkg/a.go" || got[0].Coverage != 0 || got[0].CRAP != 2 {
+		t.Errorf("GetFuncStats()[0] = %+v", got[0])
+	}
+	if got[1].Name != "complex" || got[1].StartLine != 7 || got[1].Complexity != 2 || got[1].Coverage != 75 {
+		t.Errorf("GetFuncStats()[1] = %+v", got[1])
+	}
+}
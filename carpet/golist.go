@@ -0,0 +1,176 @@
+package carpet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// goListPackage - fields from `go list -json` output which are used for resolve source files
+type goListPackage struct {
+	ImportPath string
+	Dir        string
+	Standard   bool
+	Module     *goListModule
+}
+
+// goListModule - module of package from `go list -json` output
+type goListModule struct {
+	Path    string
+	Version string
+	Main    bool
+}
+
+// getDependency - get "module@version" if package is third-party (from module cache, vendor or standard library)
+func (pkg goListPackage) getDependency() string {
+	switch {
+	case pkg.Standard:
+		return "std"
+	case pkg.Module == nil || pkg.Module.Main:
+		return ""
+	case pkg.Module.Version == "":
+		return pkg.Module.Path
+	default:
+		return pkg.Module.Path + "@" + pkg.Module.Version
+	}
+}
+
+// pkgResolver - resolves import paths of packages to source directories via `go list` in directory, results are cached
+type pkgResolver struct {
+	dir      string // empty for current directory
+	mu       sync.Mutex
+	packages map[string]goListPackage
+	goList   func(dir string, importPaths []string) ([]goListPackage, error)
+	goMod    *string // go.mod file of module in directory, loaded once
+	err      error   // the first error of go command, paths are guessed after it
+}
+
+func newPkgResolver(dir string) *pkgResolver {
+	return &pkgResolver{
+		dir:      dir,
+		packages: map[string]goListPackage{},
+		goList:   runGoList,
+	}
+}
+
+// runGoList - get info about packages with one `go list` call
+func runGoList(dir string, importPaths []string) (result []goListPackage, err error) {
+	args := append([]string{"list", "-e", "-find", "-json=ImportPath,Dir,Standard,Module"}, importPaths...)
+	cmd := exec.Command("go", args...) // #nosec
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %s", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	for {
+		pkg := goListPackage{}
+		if err := decoder.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %s", err)
+		}
+		result = append(result, pkg)
+	}
+
+	return result, nil
+}
+
+// preload - load all not cached packages for files from cover profile
+func (r *pkgResolver) preload(profileFileNames []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	uniq := map[string]struct{}{}
+	for _, fileName := range profileFileNames {
+		if isLocalProfileFileName(fileName) {
+			continue
+		}
+		importPath := path.Dir(fileName)
+		if _, ok := r.packages[importPath]; !ok {
+			uniq[importPath] = struct{}{}
+		}
+	}
+	if len(uniq) == 0 {
+		return nil
+	}
+
+	importPaths := make([]string, 0, len(uniq))
+	for importPath := range uniq {
+		importPaths = append(importPaths, importPath)
+	}
+	sort.Strings(importPaths)
+
+	for _, importPath := range importPaths {
+		// cache not found packages and failed go list too, for don't run go list for them again
+		r.packages[importPath] = goListPackage{ImportPath: importPath}
+	}
+
+	packages, err := r.goList(r.dir, importPaths)
+	if err != nil {
+		r.setErr(err)
+		return err
+	}
+
+	for _, pkg := range packages {
+		r.packages[pkg.ImportPath] = pkg
+	}
+
+	return nil
+}
+
+// getPackage - get package for file name from cover profile ("github.com/user/pkg/file.go")
+func (r *pkgResolver) getPackage(profileFileName string) (goListPackage, bool) {
+	if isLocalProfileFileName(profileFileName) {
+		return goListPackage{}, false
+	}
+	if err := r.preload([]string{profileFileName}); err != nil {
+		return goListPackage{}, false
+	}
+
+	r.mu.Lock()
+	pkg := r.packages[path.Dir(profileFileName)]
+	r.mu.Unlock()
+
+	return pkg, pkg.Dir != ""
+}
+
+// getFileName - get absolute file name for file name from cover profile
+func (r *pkgResolver) getFileName(profileFileName string) (string, bool) {
+	pkg, ok := r.getPackage(profileFileName)
+	if !ok {
+		return "", false
+	}
+
+	return filepath.Join(pkg.Dir, path.Base(profileFileName)), true
+}
+
+// getGoModFilename - get go.mod file of module in directory, empty if directory is not in module
+func (r *pkgResolver) getGoModFilename() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.goMod == nil {
+		goMod, err := getGoModFilename(r.dir)
+		if err != nil {
+			r.setErr(err)
+		}
+		r.goMod = &goMod
+	}
+
+	return *r.goMod
+}
+
+// setErr - keep the first error, should be called under lock
+func (r *pkgResolver) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
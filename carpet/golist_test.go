@@ -0,0 +1,117 @@
+package carpet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_pkgResolver(t *testing.T) {
+	t.Run("real", func(t *testing.T) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resolver := newPkgResolver("")
+		fileName, ok := resolver.getFileName("github.com/msoap/go-carpet/carpet/golist.go")
+		if !ok {
+			t.Fatalf("getFileName() failed to resolve file")
+		}
+		if fileName != filepath.Join(cwd, "golist.go") {
+			t.Errorf("getFileName() got %q", fileName)
+		}
+
+		if _, ok := resolver.getFileName("github.com/msoap/go-carpet/not_exists/file.go"); ok {
+			t.Errorf("getFileName() resolved not exists package")
+		}
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		calls := [][]string{}
+		resolver := newPkgResolver("")
+		resolver.goList = func(_ string, importPaths []string) ([]goListPackage, error) {
+			calls = append(calls, importPaths)
+			return []goListPackage{
+				{ImportPath: "example.com/a", Dir: "/src/a"},
+				{ImportPath: "example.com/a/b", Dir: "/mod/b@v1.0.0"},
+			}, nil
+		}
+
+		err := resolver.preload([]string{
+			"example.com/a/file.go",
+			"example.com/a/b/file.go",
+			"example.com/a/file_other.go",
+			"_/local/file.go",
+			"/abs/file.go",
+		})
+		if err != nil {
+			t.Fatalf("preload() failed: %s", err)
+		}
+
+		fileName, ok := resolver.getFileName("example.com/a/b/file.go")
+		if !ok || fileName != filepath.Join("/mod/b@v1.0.0", "file.go") {
+			t.Errorf("getFileName() got %q", fileName)
+		}
+		if _, ok := resolver.getFileName("example.com/a/c/file.go"); ok {
+			t.Errorf("getFileName() resolved not exists package")
+		}
+		// not found package is cached too
+		if _, ok := resolver.getFileName("example.com/a/c/file.go"); ok {
+			t.Errorf("getFileName() resolved not exists package")
+		}
+
+		expectCalls := [][]string{
+			{"example.com/a", "example.com/a/b"},
+			{"example.com/a/c"},
+		}
+		if !reflect.DeepEqual(calls, expectCalls) {
+			t.Errorf("go list calls: got %v, want %v", calls, expectCalls)
+		}
+	})
+
+	t.Run("failed go list is cached", func(t *testing.T) {
+		calls := 0
+		resolver := newPkgResolver("")
+		resolver.goList = func(string, []string) ([]goListPackage, error) {
+			calls++
+			return nil, fmt.Errorf("go list failed")
+		}
+
+		if err := resolver.preload([]string{"example.com/a/file.go", "example.com/b/file.go"}); err == nil {
+			t.Errorf("preload() error is not returned")
+		}
+		for _, fileName := range []string{"example.com/a/file.go", "example.com/b/file.go", "example.com/a/file.go"} {
+			if _, ok := resolver.getFileName(fileName); ok {
+				t.Errorf("getFileName() resolved %q after failed go list", fileName)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("go list calls: got %d, want 1", calls)
+		}
+	})
+}
+
+func Test_goListPackage_getDependency(t *testing.T) {
+	tests := []struct {
+		name string
+		pkg  goListPackage
+		want string
+	}{
+		{name: "own", pkg: goListPackage{Module: &goListModule{Path: "example.com/own", Main: true}}, want: ""},
+		{name: "without module", pkg: goListPackage{}, want: ""},
+		{name: "std", pkg: goListPackage{Standard: true}, want: "std"},
+		{name: "module cache", pkg: goListPackage{Module: &goListModule{Path: "example.com/dep", Version: "v1.2.3"}}, want: "example.com/dep@v1.2.3"},
+		{name: "replaced", pkg: goListPackage{Module: &goListModule{Path: "example.com/dep"}}, want: "example.com/dep"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pkg.getDependency(); got != tt.want {
+				t.Errorf("getDependency() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
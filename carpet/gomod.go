@@ -0,0 +1,60 @@
+package carpet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/msoap/byline"
+)
+
+// getGoModFilename - get go.mod file of module in directory (empty for current), empty if directory is not in module
+func getGoModFilename(dir string) (string, error) {
+	cmd := exec.Command("go", "env", "GOMOD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to load 'go env GOMOD' content: %s", err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+func guessAbsPathInGoMod(modFilename, relPath string) (string, error) {
+	if modFilename == "" {
+		return "", errIsNotInGoMod
+	}
+
+	modContent, err := os.ReadFile(modFilename) // #nosec
+	if err != nil {
+		return "", err
+	}
+
+	moduleName := ""
+	if err := byline.NewReader(bytes.NewReader(modContent)).AWKMode(func(_ string, fields []string, vars byline.AWKVars) (string, error) {
+		if vars.NF == 2 && fields[0] == "module" && fields[1] != "" {
+			moduleName = fields[1]
+			return "", io.EOF
+		}
+
+		return "", nil
+	}).Discard(); err != nil {
+		return "", err
+	}
+	if moduleName == "" {
+		return "", errIsNotInGoMod
+	}
+
+	absPath := path.Dir(modFilename) + strings.TrimPrefix(relPath, moduleName)
+	if stat, err := os.Stat(absPath); err != nil {
+		return "", err
+	} else if !stat.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not regular file", absPath)
+	}
+
+	return absPath, nil
+}
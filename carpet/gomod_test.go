@@ -1,4 +1,4 @@
-package main
+package carpet
 
 import (
 	"os"
@@ -9,15 +9,19 @@ func Test_guessAbsPathInGoMod(t *testing.T) {
 	if err := os.Setenv("GO111MODULE", "on"); err != nil {
 		t.Fatalf("failed to set env: %s", err)
 	}
+	modFilename, err := getGoModFilename("")
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	t.Run("empty", func(t *testing.T) {
-		if _, err := guessAbsPathInGoMod(""); err == nil {
+		if _, err := guessAbsPathInGoMod(modFilename, ""); err == nil {
 			t.Errorf("failed to test empty file")
 		}
 	})
 
 	t.Run("real", func(t *testing.T) {
-		gotAbsPath, err := guessAbsPathInGoMod("github.com/msoap/go-carpet/terminal_posix.go")
+		gotAbsPath, err := guessAbsPathInGoMod(modFilename, "github.com/msoap/go-carpet/terminal_posix.go")
 		if err != nil {
 			t.Errorf("failed to test real file: %s", err)
 		}
@@ -27,8 +31,14 @@ func Test_guessAbsPathInGoMod(t *testing.T) {
 		}
 	})
 
+	t.Run("not in module", func(t *testing.T) {
+		if _, err := guessAbsPathInGoMod("", "github.com/msoap/go-carpet/terminal_posix.go"); err != errIsNotInGoMod {
+			t.Errorf("failed to test file without module: %v", err)
+		}
+	})
+
 	t.Run("not exists", func(t *testing.T) {
-		_, err := guessAbsPathInGoMod("github.com/msoap/go-carpet/terminal_posix_another_file.go")
+		_, err := guessAbsPathInGoMod(modFilename, "github.com/msoap/go-carpet/terminal_posix_another_file.go")
 		if err == nil {
 			t.Errorf("failed to test not exists file")
 		}
@@ -0,0 +1,75 @@
+package carpet
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// HTMLStyle - CSS classes of covered and not covered code in HTML output
+const HTMLStyle = `pre { background: #1e1e1e; color: #aaa; padding: 1em; }
+.cov { color: #4ec94e; }
+.uncov { color: #f44; }
+`
+
+// HTMLRenderer - renders source of files as HTML page, covered code in "cov" spans with execution count in title,
+// not covered code in "uncov" spans
+type HTMLRenderer struct{}
+
+// BeginReport - write header of page
+func (renderer *HTMLRenderer) BeginReport(w io.Writer) error {
+	_, err := io.WriteString(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>go-carpet</title>\n<style>\n"+
+		HTMLStyle+"</style>\n</head>\n<body>\n")
+	return err
+}
+
+// BeginFile - write file name with coverage
+func (renderer *HTMLRenderer) BeginFile(w io.Writer, file FileCover) error {
+	_, err := fmt.Fprintf(w, "<h2>%s - %.1f%%</h2>\n", html.EscapeString(strings.TrimLeft(file.Profile.FileName, "_")), file.Coverage())
+	return err
+}
+
+// BeginRange - start preformatted source
+func (renderer *HTMLRenderer) BeginRange(w io.Writer, _ TextRange) error {
+	_, err := io.WriteString(w, "<pre>")
+	return err
+}
+
+// Segment - write escaped source, covered and not covered code in spans
+func (renderer *HTMLRenderer) Segment(w io.Writer, segment Segment) error {
+	text := html.EscapeString(string(segment.Text))
+
+	var err error
+	switch segment.State {
+	case CoverCovered:
+		_, err = fmt.Fprintf(w, `<span class="cov" title="%d">%s</span>`, segment.Count, text)
+	case CoverUncovered:
+		_, err = fmt.Fprintf(w, `<span class="uncov" title="0">%s</span>`, text)
+	default:
+		_, err = io.WriteString(w, text)
+	}
+
+	return err
+}
+
+// EndRange - end preformatted source
+func (renderer *HTMLRenderer) EndRange(w io.Writer) error {
+	_, err := io.WriteString(w, "</pre>\n")
+	return err
+}
+
+// EndFile - nothing to do for HTML output
+func (renderer *HTMLRenderer) EndFile(io.Writer) error { return nil }
+
+// Summary - write total coverage
+func (renderer *HTMLRenderer) Summary(w io.Writer, summary Summary) error {
+	_, err := fmt.Fprintf(w, "<p>Coverage: %.1f%% of statements</p>\n", summary.Coverage)
+	return err
+}
+
+// EndReport - write footer of page
+func (renderer *HTMLRenderer) EndReport(w io.Writer) error {
+	_, err := io.WriteString(w, "</body>\n</html>\n")
+	return err
+}
@@ -0,0 +1,73 @@
+package carpet
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONRenderer - renders coverage of files and functions as one JSON document
+type JSONRenderer struct {
+	report jsonReport
+}
+
+type jsonReport struct {
+	Files   []jsonFile `json:"files"`
+	Summary *Summary   `json:"summary,omitempty"`
+}
+
+type jsonFile struct {
+	FileStat
+	Dependency     string     `json:"dependency,omitempty"`
+	UncoveredLines []string   `json:"uncovered_lines"`
+	Funcs          []FuncStat `json:"funcs"`
+}
+
+// BeginReport - start new report
+func (renderer *JSONRenderer) BeginReport(io.Writer) error {
+	renderer.report = jsonReport{Files: []jsonFile{}}
+	return nil
+}
+
+// BeginFile - collect stat of file
+func (renderer *JSONRenderer) BeginFile(_ io.Writer, file FileCover) error {
+	result := jsonFile{
+		FileStat:       GetFileStats([]FileCover{file})[0],
+		Dependency:     file.Dependency,
+		UncoveredLines: []string{},
+		Funcs:          GetFuncStats([]FileCover{file}),
+	}
+	for _, lineRange := range UncoveredLineRanges(file.Profile.Blocks) {
+		result.UncoveredLines = append(result.UncoveredLines, lineRange.String())
+	}
+	if result.Funcs == nil {
+		result.Funcs = []FuncStat{}
+	}
+
+	renderer.report.Files = append(renderer.report.Files, result)
+	return nil
+}
+
+// BeginRange - source is not rendered in JSON
+func (renderer *JSONRenderer) BeginRange(io.Writer, TextRange) error { return nil }
+
+// Segment - source is not rendered in JSON
+func (renderer *JSONRenderer) Segment(io.Writer, Segment) error { return nil }
+
+// EndRange - source is not rendered in JSON
+func (renderer *JSONRenderer) EndRange(io.Writer) error { return nil }
+
+// EndFile - nothing to do for JSON
+func (renderer *JSONRenderer) EndFile(io.Writer) error { return nil }
+
+// Summary - collect total coverage
+func (renderer *JSONRenderer) Summary(_ io.Writer, summary Summary) error {
+	renderer.report.Summary = &summary
+	return nil
+}
+
+// EndReport - write JSON document
+func (renderer *JSONRenderer) EndReport(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(renderer.report)
+}
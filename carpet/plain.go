@@ -0,0 +1,88 @@
+package carpet
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// markers of lines in plain output
+const (
+	PlainMarkerCovered   = "+ "
+	PlainMarkerUncovered = "- "
+	PlainMarkerNone      = "  "
+)
+
+// PlainRenderer - renders source of files without colors, each line prefixed with marker:
+// "-" - has not covered code, "+" - covered code
+type PlainRenderer struct {
+	line   []byte
+	marker string
+}
+
+// BeginReport - nothing to do for plain output
+func (renderer *PlainRenderer) BeginReport(io.Writer) error { return nil }
+
+// BeginFile - write file name with coverage
+func (renderer *PlainRenderer) BeginFile(w io.Writer, file FileCover) error {
+	header := fmt.Sprintf("%s - %.1f%%", strings.TrimLeft(file.Profile.FileName, "_"), file.Coverage())
+	_, err := fmt.Fprintf(w, "%s\n%s\n", header, strings.Repeat("~", len(header)))
+	return err
+}
+
+// BeginRange - start new line
+func (renderer *PlainRenderer) BeginRange(io.Writer, TextRange) error {
+	renderer.line, renderer.marker = renderer.line[:0], PlainMarkerNone
+	return nil
+}
+
+// Segment - collect line, marker of line depends on all segments of line
+func (renderer *PlainRenderer) Segment(w io.Writer, segment Segment) error {
+	for _, char := range segment.Text {
+		if char == '\n' {
+			if err := renderer.writeLine(w); err != nil {
+				return err
+			}
+			continue
+		}
+
+		renderer.line = append(renderer.line, char)
+		switch {
+		case segment.State == CoverUncovered:
+			renderer.marker = PlainMarkerUncovered
+		case segment.State == CoverCovered && renderer.marker == PlainMarkerNone:
+			renderer.marker = PlainMarkerCovered
+		}
+	}
+
+	return nil
+}
+
+// EndRange - write last line of range
+func (renderer *PlainRenderer) EndRange(w io.Writer) error {
+	return renderer.writeLine(w)
+}
+
+func (renderer *PlainRenderer) writeLine(w io.Writer) error {
+	result := []byte{}
+	if renderer.marker != PlainMarkerNone || len(renderer.line) > 0 {
+		result = append(result, renderer.marker...)
+	}
+	result = append(append(result, renderer.line...), '\n')
+	renderer.line, renderer.marker = renderer.line[:0], PlainMarkerNone
+
+	_, err := w.Write(result)
+	return err
+}
+
+// EndFile - nothing to do for plain output
+func (renderer *PlainRenderer) EndFile(io.Writer) error { return nil }
+
+// Summary - write total coverage
+func (renderer *PlainRenderer) Summary(w io.Writer, summary Summary) error {
+	_, err := fmt.Fprintf(w, "Coverage: %.1f%% of statements\n", summary.Coverage)
+	return err
+}
+
+// EndReport - nothing to do for plain output
+func (renderer *PlainRenderer) EndReport(io.Writer) error { return nil }
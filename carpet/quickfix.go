@@ -0,0 +1,87 @@
+package carpet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+)
+
+// QuickfixRenderer - renders not covered blocks as "path:line:col: message" lines for Vim quickfix list
+// and Emacs compilation mode, paths are absolute paths of source files, with RenderOptions.Funcs only blocks of these functions
+type QuickfixRenderer struct {
+	file       FileCover
+	withRanges bool // blocks are written by ranges, without source (RenderOptions.WithoutSource) all blocks are written in EndFile
+}
+
+// BeginReport - nothing to do for quickfix output
+func (renderer *QuickfixRenderer) BeginReport(io.Writer) error { return nil }
+
+// BeginFile - start file, not covered blocks are written by ranges of functions
+func (renderer *QuickfixRenderer) BeginFile(_ io.Writer, file FileCover) error {
+	renderer.file, renderer.withRanges = file, false
+	return nil
+}
+
+// writeBlocks - write not covered blocks which start in lines from begin to end
+func (renderer *QuickfixRenderer) writeBlocks(w io.Writer, beginLine, endLine int) error {
+	for _, block := range renderer.file.Profile.Blocks {
+		if block.Count > 0 || block.NumStmt == 0 || block.StartLine < beginLine || block.StartLine > endLine {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "%s:%d:%d: uncovered (%d statements)\n", renderer.file.FileName, block.StartLine, block.StartCol, block.NumStmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BeginRange - write not covered blocks of function (or whole file), source is not rendered in quickfix output
+func (renderer *QuickfixRenderer) BeginRange(w io.Writer, textRange TextRange) error {
+	renderer.withRanges = true
+	beginLine, endLine := getRangeLines(renderer.file.Content, textRange)
+
+	return renderer.writeBlocks(w, beginLine, endLine)
+}
+
+// getRangeLines - lines of range of source, the end line is math.MaxInt for range to the end of file
+func getRangeLines(content []byte, textRange TextRange) (beginLine, endLine int) {
+	begin, end := textRange.Begin, textRange.End
+	if end > len(content) {
+		end = len(content)
+	}
+	if begin > end {
+		begin = end
+	}
+	beginLine = bytes.Count(content[:begin], []byte("\n")) + 1
+	endLine = beginLine + bytes.Count(content[begin:end], []byte("\n"))
+	if textRange.End >= len(content) {
+		// the whole file or the last function
+		endLine = math.MaxInt
+	}
+
+	return beginLine, endLine
+}
+
+// Segment - source is not rendered in quickfix output
+func (renderer *QuickfixRenderer) Segment(io.Writer, Segment) error { return nil }
+
+// EndRange - source is not rendered in quickfix output
+func (renderer *QuickfixRenderer) EndRange(io.Writer) error { return nil }
+
+// EndFile - write all not covered blocks of file if source is not rendered
+func (renderer *QuickfixRenderer) EndFile(w io.Writer) error {
+	if renderer.withRanges {
+		return nil
+	}
+
+	return renderer.writeBlocks(w, 0, math.MaxInt)
+}
+
+// Summary - total coverage is not shown in quickfix output
+func (renderer *QuickfixRenderer) Summary(io.Writer, Summary) error { return nil }
+
+// EndReport - nothing to do for quickfix output
+func (renderer *QuickfixRenderer) EndReport(io.Writer) error { return nil }
@@ -0,0 +1,285 @@
+package carpet
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"golang.org/x/tools/cover"
+)
+
+// TextRange - range of bytes in source file, from 0
+type TextRange struct {
+	Begin, End int
+}
+
+// FuncRanges - get ranges of functions in source, all source if funcs is empty
+func FuncRanges(fileBytes []byte, funcs []string) (result []TextRange, err error) {
+	if len(funcs) == 0 {
+		return []TextRange{{
+			Begin: 0,
+			End:   len(fileBytes),
+		}}, nil
+	}
+
+	golangFuncs, err := GetGolangFuncs(fileBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, existsFunc := range golangFuncs {
+		for _, filterFuncName := range funcs {
+			if existsFunc.Name == filterFuncName {
+				result = append(result, TextRange{Begin: existsFunc.Begin - 1, End: existsFunc.End - 1})
+			}
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("filter by functions: %v - not found", funcs)
+	}
+
+	return result, nil
+}
+
+// CoverState - coverage state of byte of source
+type CoverState int
+
+// coverage states
+const (
+	CoverNone CoverState = iota
+	CoverCovered
+	CoverUncovered
+)
+
+// CoverStates - get coverage state of each byte of source
+func CoverStates(boundaries []cover.Boundary, length int) []CoverState {
+	result := make([]CoverState, length)
+
+	state, prevOffset := CoverNone, 0
+	for _, boundary := range boundaries {
+		for offset := prevOffset; offset < boundary.Offset && offset < length; offset++ {
+			result[offset] = state
+		}
+
+		switch {
+		case boundary.Start && boundary.Count > 0:
+			state = CoverCovered
+		case boundary.Start && boundary.Count == 0:
+			state = CoverUncovered
+		default:
+			state = CoverNone
+		}
+		prevOffset = boundary.Offset
+	}
+
+	for offset := prevOffset; offset < length; offset++ {
+		result[offset] = state
+	}
+
+	return result
+}
+
+// Segment - part of source with the same coverage, between boundaries of blocks
+type Segment struct {
+	Text   []byte // may be empty for adjacent boundaries
+	Offset int    // offset of text in source file
+	State  CoverState
+	Count  int     // execution count of block
+	Norm   float64 // execution count normalized in file on logarithmic scale, in [0, 1]
+}
+
+// Summary - total coverage of all rendered files
+type Summary struct {
+	Files      int     `json:"files"`
+	Statements int     `json:"statements"`
+	Covered    int     `json:"covered"`
+	Coverage   float64 `json:"coverage"` // in percent
+	Mode       string  `json:"mode"`     // cover mode: set, count or atomic
+}
+
+// GetSummary - get total coverage of files
+func GetSummary(files []FileCover) (result Summary) {
+	blocks := []cover.ProfileBlock{}
+	for _, file := range files {
+		blocks = append(blocks, file.Profile.Blocks...)
+		if result.Mode == "" {
+			result.Mode = file.Profile.Mode
+		}
+	}
+
+	result.Files = len(files)
+	result.Statements, result.Covered = countStatements(blocks)
+	result.Coverage = Coverage(blocks)
+
+	return result
+}
+
+// Renderer - output format of coverage, methods are called in order:
+//
+//	BeginReport
+//	for each file: BeginFile, for each function range (or whole file): BeginRange, Segment..., EndRange; EndFile
+//	Summary
+//	EndReport
+type Renderer interface {
+	BeginReport(w io.Writer) error
+	BeginFile(w io.Writer, file FileCover) error
+	BeginRange(w io.Writer, textRange TextRange) error
+	Segment(w io.Writer, segment Segment) error
+	EndRange(w io.Writer) error
+	EndFile(w io.Writer) error
+	Summary(w io.Writer, summary Summary) error
+	EndReport(w io.Writer) error
+}
+
+// RenderOptions - options of rendering
+type RenderOptions struct {
+	// show only these functions, files without them are skipped, all source if empty
+	Funcs []string
+	// don't render source, only BeginFile and EndFile are called for each file
+	WithoutSource bool
+}
+
+// Render - render full report of files
+func Render(w io.Writer, renderer Renderer, files []FileCover, options RenderOptions) error {
+	if err := renderer.BeginReport(w); err != nil {
+		return err
+	}
+	if err := RenderFiles(w, renderer, files, options); err != nil {
+		return err
+	}
+	if len(files) > 0 {
+		if err := renderer.Summary(w, GetSummary(files)); err != nil {
+			return err
+		}
+	}
+
+	return renderer.EndReport(w)
+}
+
+// RenderFiles - render files without begin and end of report, for render files of packages one by one
+func RenderFiles(w io.Writer, renderer Renderer, files []FileCover, options RenderOptions) error {
+	for _, file := range files {
+		if err := renderFile(w, renderer, file, options); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func renderFile(w io.Writer, renderer Renderer, file FileCover, options RenderOptions) error {
+	textRanges, err := FuncRanges(file.Content, options.Funcs)
+	if err != nil {
+		// file without filtered functions
+		return nil
+	}
+
+	if err := renderer.BeginFile(w, file); err != nil {
+		return err
+	}
+
+	if !options.WithoutSource {
+		boundaries := file.Profile.Boundaries(file.Content)
+		states := CoverStates(boundaries, len(file.Content))
+		for _, textRange := range textRanges {
+			if err := renderRange(w, renderer, file.Content, textRange, boundaries, states); err != nil {
+				return err
+			}
+		}
+	}
+
+	return renderer.EndFile(w)
+}
+
+func renderRange(w io.Writer, renderer Renderer, fileBytes []byte, textRange TextRange, boundaries []cover.Boundary, states []CoverState) error {
+	if err := renderer.BeginRange(w, textRange); err != nil {
+		return err
+	}
+
+	segment := Segment{Offset: textRange.Begin}
+	if textRange.Begin < len(states) {
+		segment.State = states[textRange.Begin]
+	}
+
+	for _, boundary := range boundaries {
+		if boundary.Offset < textRange.Begin || boundary.Offset > textRange.End {
+			// skip boundary which is not in filter function
+			continue
+		}
+
+		segment.Text = fileBytes[segment.Offset:boundary.Offset]
+		if err := renderer.Segment(w, segment); err != nil {
+			return err
+		}
+
+		segment = Segment{Offset: boundary.Offset}
+		switch {
+		case boundary.Start && boundary.Count > 0:
+			segment.State, segment.Count, segment.Norm = CoverCovered, boundary.Count, boundary.Norm
+		case boundary.Start && boundary.Count == 0:
+			segment.State = CoverUncovered
+		}
+	}
+
+	end := textRange.End
+	if end > len(fileBytes) {
+		end = len(fileBytes)
+	}
+	if segment.Offset <= end {
+		segment.Text = fileBytes[segment.Offset:end]
+		if err := renderer.Segment(w, segment); err != nil {
+			return err
+		}
+	}
+
+	return renderer.EndRange(w)
+}
+
+var (
+	renderersMu sync.Mutex
+	renderers   = map[string]func() Renderer{
+		"plain":    func() Renderer { return &PlainRenderer{} },
+		"json":     func() Renderer { return &JSONRenderer{} },
+		"html":     func() Renderer { return &HTMLRenderer{} },
+		"quickfix": func() Renderer { return &QuickfixRenderer{} },
+		"sarif":    func() Renderer { return &SARIFRenderer{} },
+		"sonar":    func() Renderer { return &SonarRenderer{} },
+	}
+)
+
+// RegisterRenderer - register output format by name, it replaces registered renderer with the same name
+func RegisterRenderer(name string, newRenderer func() Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+
+	renderers[name] = newRenderer
+}
+
+// NewRenderer - get new renderer of registered output format
+func NewRenderer(name string) (Renderer, error) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+
+	newRenderer, ok := renderers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown renderer: %q", name)
+	}
+
+	return newRenderer(), nil
+}
+
+// RendererNames - sorted names of registered output formats
+func RendererNames() []string {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+
+	result := make([]string, 0, len(renderers))
+	for name := range renderers {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+
+	return result
+}
@@ -0,0 +1,359 @@
+package carpet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func Test_CoverStates(t *testing.T) {
+	boundaries := []cover.Boundary{
+		{Offset: 2, Start: true, Count: 1},
+		{Offset: 4, Start: false},
+		{Offset: 5, Start: true, Count: 0},
+	}
+
+	got := CoverStates(boundaries, 7)
+	want := []CoverState{CoverNone, CoverNone, CoverCovered, CoverCovered, CoverNone, CoverUncovered, CoverUncovered}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CoverStates():\ngot : %v\nwant: %v", got, want)
+	}
+}
+
+func Test_PlainRenderer(t *testing.T) {
+	files := []FileCover{{
+		Profile: &cover.Profile{FileName: "_/src/file.go", Mode: "count", Blocks: []cover.ProfileBlock{
+			{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 8, NumStmt: 1, Count: 1},
+			{StartLine: 3, StartCol: 1, EndLine: 3, EndCol: 6, NumStmt: 1, Count: 0},
+		}},
+		Content: []byte("package x\ncovered\nnotup\n"),
+	}}
+
+	out := &bytes.Buffer{}
+	if err := Render(out, &PlainRenderer{}, files, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "/src/file.go - 50.0%\n~~~~~~~~~~~~~~~~~~~~\n" +
+		"  package x\n" +
+		"+ covered\n" +
+		"- notup\n" +
+		"\n" +
+		"Coverage: 50.0% of statements\n"
+	if out.String() != want {
+		t.Errorf("Render() plain:\ngot : %q\nwant: %q", out.String(), want)
+	}
+
+	// file without function is skipped
+	out.Reset()
+	if err := RenderFiles(out, &PlainRenderer{}, files, RenderOptions{Funcs: []string{"fn"}}); err != nil || out.Len() != 0 {
+		t.Errorf("RenderFiles() with not exists function: %q, %v", out.String(), err)
+	}
+}
+
+// segmentsRenderer - records calls of renderer
+type segmentsRenderer struct {
+	calls []string
+}
+
+func (r *segmentsRenderer) BeginReport(io.Writer) error { r.add("begin report"); return nil }
+func (r *segmentsRenderer) BeginFile(_ io.Writer, file FileCover) error {
+	r.add("begin file " + file.Profile.FileName)
+	return nil
+}
+func (r *segmentsRenderer) BeginRange(_ io.Writer, textRange TextRange) error {
+	r.add(fmt.Sprintf("begin range %d-%d", textRange.Begin, textRange.End))
+	return nil
+}
+func (r *segmentsRenderer) Segment(_ io.Writer, segment Segment) error {
+	r.add(fmt.Sprintf("segment %q %d %d", segment.Text, segment.State, segment.Count))
+	return nil
+}
+func (r *segmentsRenderer) EndRange(io.Writer) error { r.add("end range"); return nil }
+func (r *segmentsRenderer) EndFile(io.Writer) error  { r.add("end file"); return nil }
+func (r *segmentsRenderer) Summary(_ io.Writer, summary Summary) error {
+	r.add(fmt.Sprintf("summary %d/%d %s", summary.Covered, summary.Statements, summary.Mode))
+	return nil
+}
+func (r *segmentsRenderer) EndReport(io.Writer) error { r.add("end report"); return nil }
+func (r *segmentsRenderer) add(call string)           { r.calls = append(r.calls, call) }
+
+func Test_Render(t *testing.T) {
+	files := []FileCover{{
+		Profile: &cover.Profile{FileName: "file.go", Mode: "count", Blocks: []cover.ProfileBlock{
+			{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 4, NumStmt: 1, Count: 3},
+			{StartLine: 2, StartCol: 4, EndLine: 2, EndCol: 6, NumStmt: 1, Count: 0},
+		}},
+		Content: []byte("package x\nabcdef\n"),
+	}}
+
+	RegisterRenderer("test", func() Renderer { return &segmentsRenderer{} })
+	renderer, err := NewRenderer("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Render(io.Discard, renderer, files, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"begin report",
+		"begin file file.go",
+		"begin range 0-17",
+		`segment "package x\n" 0 0`,
+		`segment "abc" 1 3`,
+		`segment "" 0 0`,
+		`segment "de" 2 0`,
+		`segment "f\n" 0 0`,
+		"end range",
+		"end file",
+		"summary 1/2 count",
+		"end report",
+	}
+	if got := renderer.(*segmentsRenderer).calls; !reflect.DeepEqual(got, want) {
+		t.Errorf("Render() calls:\ngot : %q\nwant: %q", got, want)
+	}
+
+	if _, err := NewRenderer("not exists"); err == nil {
+		t.Errorf("NewRenderer() not got error for unknown renderer")
+	}
+	if names := RendererNames(); !reflect.DeepEqual(names, []string{"html", "json", "plain", "quickfix", "sarif", "sonar", "test"}) {
+		t.Errorf("RendererNames() = %v", names)
+	}
+}
+
+func Test_HTMLRenderer(t *testing.T) {
+	files := []FileCover{{
+		Profile: &cover.Profile{FileName: "_/src/a<b>.go", Mode: "count", Blocks: []cover.ProfileBlock{
+			{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 7, NumStmt: 1, Count: 3},
+			{StartLine: 3, StartCol: 1, EndLine: 3, EndCol: 6, NumStmt: 1, Count: 0},
+		}},
+		Content: []byte("a < b\nx && y\nz > 0\n"),
+	}}
+
+	out := &bytes.Buffer{}
+	if err := Render(out, &HTMLRenderer{}, files, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "<h2>/src/a&lt;b&gt;.go - 50.0%</h2>\n" +
+		`<pre>a &lt; b` + "\n" +
+		`<span class="cov" title="3">x &amp;&amp; y</span>` + "\n" +
+		`<span class="uncov" title="0">z &gt; 0</span>` + "\n" +
+		"</pre>\n" +
+		"<p>Coverage: 50.0% of statements</p>\n" +
+		"</body>\n</html>\n"
+	if got := out.String(); !strings.HasPrefix(got, "<!DOCTYPE html>") || !strings.HasSuffix(got, want) {
+		t.Errorf("Render() html:\ngot : %q\nwant suffix: %q", got, want)
+	}
+}
+
+func Test_JSONRenderer(t *testing.T) {
+	files := []FileCover{{
+		Profile: &cover.Profile{FileName: "example.com/pkg/file.go", Mode: "set", Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 13, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 0},
+		}},
+		Content:    []byte("package pkg\n\nfunc fn() int {\n\treturn 1\n}\n"),
+		Dependency: "example.com/pkg@v1.0.0",
+	}}
+
+	out := &bytes.Buffer{}
+	if err := Render(out, &JSONRenderer{}, files, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "files": [
+    {
+      "file_name": "example.com/pkg/file.go",
+      "package": "example.com/pkg",
+      "statements": 1,
+      "covered": 0,
+      "coverage": 0,
+      "dependency": "example.com/pkg@v1.0.0",
+      "uncovered_lines": [
+        "3-5"
+      ],
+      "funcs": [
+        {
+          "name": "fn",
+          "start_line": 3,
+          "end_line": 5,
+          "complexity": 1,
+          "file_name": "example.com/pkg/file.go",
+          "coverage": 0,
+          "crap": 2
+        }
+      ]
+    }
+  ],
+  "summary": {
+    "files": 1,
+    "statements": 1,
+    "covered": 0,
+    "coverage": 0,
+    "mode": "set"
+  }
+}
+`
+	if out.String() != want {
+		t.Errorf("Render() JSON:\ngot : %s\nwant: %s", out.String(), want)
+	}
+}
+
+func Test_QuickfixRenderer(t *testing.T) {
+	files := []FileCover{{
+		Profile: &cover.Profile{FileName: "example.com/pkg/file.go", Mode: "count", Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 15, EndLine: 5, EndCol: 2, NumStmt: 2, Count: 0},
+			{StartLine: 7, StartCol: 15, EndLine: 9, EndCol: 2, NumStmt: 1, Count: 3},
+			{StartLine: 11, StartCol: 15, EndLine: 13, EndCol: 2, NumStmt: 1, Count: 0},
+		}},
+		FileName: "/src/pkg/file.go",
+		Content:  []byte("package pkg\n"),
+	}}
+
+	out := &bytes.Buffer{}
+	if err := Render(out, &QuickfixRenderer{}, files, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "/src/pkg/file.go:3:15: uncovered (2 statements)\n" +
+		"/src/pkg/file.go:11:15: uncovered (1 statements)\n"
+	if out.String() != want {
+		t.Errorf("Render() quickfix:\ngot : %q\nwant: %q", out.String(), want)
+	}
+
+	out.Reset()
+	if err := Render(out, &QuickfixRenderer{}, files, RenderOptions{WithoutSource: true}); err != nil || out.String() != want {
+		t.Errorf("Render() quickfix without source: %q, %v", out.String(), err)
+	}
+
+	files[0].Content = []byte("package pkg\n\nfunc a(x int) {\n\tx++\n}\n\nfunc b(x int) {\n\tx++\n}\n\nfunc c(x int) {\n\tx++\n}\n")
+	for _, funcs := range [][]string{{"c"}, {"b"}} {
+		out.Reset()
+		if err := Render(out, &QuickfixRenderer{}, files, RenderOptions{Funcs: funcs}); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{"c": "/src/pkg/file.go:11:15: uncovered (1 statements)\n", "b": ""}[funcs[0]]
+		if out.String() != want {
+			t.Errorf("Render() quickfix with -func %v:\ngot : %q\nwant: %q", funcs, out.String(), want)
+		}
+	}
+}
+
+func Test_SARIFRenderer(t *testing.T) {
+	files := []FileCover{{
+		Profile: &cover.Profile{FileName: "example.com/pkg/file.go", Mode: "count", Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 13, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 0},
+			{StartLine: 7, StartCol: 18, EndLine: 8, EndCol: 11, NumStmt: 1, Count: 1},
+			{StartLine: 8, StartCol: 11, EndLine: 10, EndCol: 3, NumStmt: 1, Count: 0},
+		}},
+		FileName: "/src/repo/pkg/file.go",
+		Content:  []byte("package pkg\n\nfunc a() int {\n\treturn 1\n}\n\nfunc b(x int) int {\n\tif x > 0 {\n\t\treturn 1\n\t}\n\treturn 0\n}\n"),
+	}}
+
+	out := &bytes.Buffer{}
+	if err := Render(out, &SARIFRenderer{Root: "/src/repo"}, files, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := sarifLog{}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Version != "2.1.0" || len(result.Runs) != 1 || len(result.Runs[0].Results) != 2 {
+		t.Fatalf("Render() SARIF:\n%s", out.String())
+	}
+
+	fn, block := result.Runs[0].Results[0], result.Runs[0].Results[1]
+	if fn.RuleID != SARIFRuleUncoveredFunction || fn.Message.Text != "Function a is not covered by tests" ||
+		fn.Locations[0].PhysicalLocation.Region != (sarifRegion{StartLine: 3, EndLine: 5}) {
+		t.Errorf("SARIF function result: %+v", fn)
+	}
+	if block.RuleID != SARIFRuleUncoveredBlock || block.RuleIndex != 1 ||
+		block.Locations[0].PhysicalLocation.ArtifactLocation != (sarifArtifactLocation{URI: "pkg/file.go", URIBaseID: "SRCROOT"}) ||
+		block.Locations[0].PhysicalLocation.Region != (sarifRegion{StartLine: 8, StartColumn: 11, EndLine: 10, EndColumn: 3}) {
+		t.Errorf("SARIF block result: %+v", block)
+	}
+	if coverage, ok := block.Properties["fileCoverage"].(float64); !ok || math.Abs(coverage-100.0/3) > 1e-9 {
+		t.Errorf("SARIF block properties: %v", block.Properties)
+	}
+
+	for _, options := range []RenderOptions{{Funcs: []string{"b"}}, {Funcs: []string{"b"}, WithoutSource: true}} {
+		out.Reset()
+		if err := Render(out, &SARIFRenderer{}, files, options); err != nil {
+			t.Fatal(err)
+		}
+		result := sarifLog{}
+		if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		want := 1 // only block of function b
+		if options.WithoutSource {
+			want = 2 // without source all results of file
+		}
+		if got := len(result.Runs[0].Results); got != want {
+			t.Errorf("Render() SARIF with %+v: got %d results, want %d", options, got, want)
+		}
+	}
+
+	if location := (&SARIFRenderer{}).getArtifactLocation("/src/repo/pkg/file.go"); location.URI != "file:///src/repo/pkg/file.go" || location.URIBaseID != "" {
+		t.Errorf("getArtifactLocation() without root: %+v", location)
+	}
+}
+
+func Test_SonarRenderer(t *testing.T) {
+	files := []FileCover{{
+		Profile: &cover.Profile{FileName: "example.com/pkg/file.go", Mode: "count", Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 15, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 0},
+		}},
+		FileName: "/src/repo/pkg/file.go",
+		Content:  []byte("package pkg\n\nfunc fn() int {\n\treturn 1\n}\n"),
+	}, {
+		Profile: &cover.Profile{FileName: "example.com/other/file.go", Mode: "count", Blocks: []cover.ProfileBlock{
+			{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 10, NumStmt: 1, Count: 2},
+		}},
+		FileName: "/other/file.go",
+		Content:  []byte("x := f(1)\n"),
+	}}
+
+	out := &bytes.Buffer{}
+	if err := Render(out, &SonarRenderer{Root: "/src/repo"}, files, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<coverage version="1">
+  <file path="pkg/file.go">
+    <lineToCover lineNumber="4" covered="false"></lineToCover>
+  </file>
+  <file path="/other/file.go">
+    <lineToCover lineNumber="1" covered="true"></lineToCover>
+  </file>
+</coverage>
+`
+	if out.String() != want {
+		t.Errorf("Render() SonarQube:\ngot : %s\nwant: %s", out.String(), want)
+	}
+
+	// only lines of selected functions, file without them is skipped
+	files[0].Content = []byte("package pkg\n\nfunc fn() int {\n\treturn 1\n}\n\nfunc other() int {\n\treturn 2\n}\n")
+	files[0].Profile.Blocks = append(files[0].Profile.Blocks, cover.ProfileBlock{StartLine: 7, StartCol: 18, EndLine: 9, EndCol: 2, NumStmt: 1, Count: 1})
+	out.Reset()
+	if err := Render(out, &SonarRenderer{Root: "/src/repo"}, files, RenderOptions{Funcs: []string{"other"}}); err != nil {
+		t.Fatal(err)
+	}
+	want = `<coverage version="1">
+  <file path="pkg/file.go">
+    <lineToCover lineNumber="8" covered="true"></lineToCover>
+  </file>
+</coverage>
+`
+	if out.String() != want {
+		t.Errorf("Render() SonarQube with functions:\ngot : %s\nwant: %s", out.String(), want)
+	}
+}
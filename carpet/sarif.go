@@ -0,0 +1,243 @@
+package carpet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// SARIF rules of coverage gaps
+const (
+	SARIFRuleUncoveredFunction = "uncovered-function"
+	SARIFRuleUncoveredBlock    = "uncovered-block"
+
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifRootID  = "SRCROOT"
+)
+
+// SARIFRenderer - renders not covered functions and blocks as SARIF 2.1.0 log for code-scanning dashboards,
+// blocks of not covered functions are reported only as function, with RenderOptions.Funcs only these functions
+type SARIFRenderer struct {
+	// Root - paths of files are relative to the root (usually root of repository), absolute file URIs if empty
+	Root string
+	// ToolVersion - version of tool in SARIF log
+	ToolVersion string
+
+	results    []sarifResult
+	file       FileCover
+	withRanges bool // results are collected by ranges, without source (RenderOptions.WithoutSource) all results are collected in EndFile
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	DefaultConfiguration map[string]string `json:"defaultConfiguration"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+var sarifRules = []sarifRule{
+	{
+		ID:                   SARIFRuleUncoveredFunction,
+		ShortDescription:     sarifMessage{Text: "Function is not covered by tests"},
+		DefaultConfiguration: map[string]string{"level": "warning"},
+	},
+	{
+		ID:                   SARIFRuleUncoveredBlock,
+		ShortDescription:     sarifMessage{Text: "Block of code is not covered by tests"},
+		DefaultConfiguration: map[string]string{"level": "note"},
+	},
+}
+
+// BeginReport - start new report
+func (renderer *SARIFRenderer) BeginReport(io.Writer) error {
+	renderer.results = []sarifResult{}
+	return nil
+}
+
+// BeginFile - start file, not covered functions and blocks are collected by ranges of functions
+func (renderer *SARIFRenderer) BeginFile(_ io.Writer, file FileCover) error {
+	renderer.file, renderer.withRanges = file, false
+	return nil
+}
+
+// addResults - collect not covered functions and blocks which start in lines from begin to end
+func (renderer *SARIFRenderer) addResults(beginLine, endLine int) {
+	file := renderer.file
+	artifact := renderer.getArtifactLocation(file.FileName)
+	fileCoverage := file.Coverage()
+
+	uncoveredFuncs := []FuncStat{}
+	for _, fn := range GetFuncStats([]FileCover{file}) {
+		if fn.Coverage > 0 || fn.StartLine < beginLine || fn.StartLine > endLine {
+			continue
+		}
+
+		uncoveredFuncs = append(uncoveredFuncs, fn)
+		renderer.results = append(renderer.results, sarifResult{
+			RuleID:    SARIFRuleUncoveredFunction,
+			RuleIndex: 0,
+			Level:     "warning",
+			Message:   sarifMessage{Text: fmt.Sprintf("Function %s is not covered by tests", fn.Name)},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: artifact,
+				Region:           sarifRegion{StartLine: fn.StartLine, EndLine: fn.EndLine},
+			}}},
+			Properties: map[string]interface{}{
+				"coverage":     fn.Coverage,
+				"fileCoverage": fileCoverage,
+				"complexity":   fn.Complexity,
+				"crap":         fn.CRAP,
+			},
+		})
+	}
+
+	for _, block := range file.Profile.Blocks {
+		if block.Count > 0 || block.NumStmt == 0 || block.StartLine < beginLine || block.StartLine > endLine ||
+			isBlockInFuncs(block.StartLine, block.EndLine, uncoveredFuncs) {
+			continue
+		}
+
+		renderer.results = append(renderer.results, sarifResult{
+			RuleID:    SARIFRuleUncoveredBlock,
+			RuleIndex: 1,
+			Level:     "note",
+			Message:   sarifMessage{Text: fmt.Sprintf("%d statements are not covered by tests", block.NumStmt)},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: artifact,
+				Region:           sarifRegion{StartLine: block.StartLine, StartColumn: block.StartCol, EndLine: block.EndLine, EndColumn: block.EndCol},
+			}}},
+			Properties: map[string]interface{}{
+				"statements":   block.NumStmt,
+				"fileCoverage": fileCoverage,
+			},
+		})
+	}
+}
+
+func isBlockInFuncs(startLine, endLine int, funcs []FuncStat) bool {
+	for _, fn := range funcs {
+		if startLine >= fn.StartLine && endLine <= fn.EndLine {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getArtifactLocation - location of file relative to root, or absolute file URI
+func (renderer *SARIFRenderer) getArtifactLocation(fileName string) sarifArtifactLocation {
+	if renderer.Root != "" {
+		if relName, err := filepath.Rel(renderer.Root, fileName); err == nil && !strings.HasPrefix(relName, "..") {
+			return sarifArtifactLocation{URI: filepath.ToSlash(relName), URIBaseID: sarifRootID}
+		}
+	}
+
+	return sarifArtifactLocation{URI: (&url.URL{Scheme: "file", Path: filepath.ToSlash(fileName)}).String()}
+}
+
+// BeginRange - collect not covered functions and blocks of function (or whole file), source is not rendered in SARIF
+func (renderer *SARIFRenderer) BeginRange(_ io.Writer, textRange TextRange) error {
+	renderer.withRanges = true
+	renderer.addResults(getRangeLines(renderer.file.Content, textRange))
+	return nil
+}
+
+// Segment - source is not rendered in SARIF
+func (renderer *SARIFRenderer) Segment(io.Writer, Segment) error { return nil }
+
+// EndRange - source is not rendered in SARIF
+func (renderer *SARIFRenderer) EndRange(io.Writer) error { return nil }
+
+// EndFile - collect all not covered functions and blocks of file if source is not rendered
+func (renderer *SARIFRenderer) EndFile(io.Writer) error {
+	if !renderer.withRanges {
+		renderer.addResults(0, math.MaxInt)
+	}
+
+	return nil
+}
+
+// Summary - total coverage is not reported in SARIF
+func (renderer *SARIFRenderer) Summary(io.Writer, Summary) error { return nil }
+
+// EndReport - write SARIF log
+func (renderer *SARIFRenderer) EndReport(w io.Writer) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "go-carpet",
+			Version:        renderer.ToolVersion,
+			InformationURI: "https://github.com/msoap/go-carpet",
+			Rules:          sarifRules,
+		}},
+		Results: renderer.results,
+	}
+	if renderer.Root != "" {
+		rootURI := (&url.URL{Scheme: "file", Path: filepath.ToSlash(renderer.Root) + "/"}).String()
+		run.OriginalURIBaseIDs = map[string]sarifArtifactLocation{sarifRootID: {URI: rootURI}}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
@@ -0,0 +1,106 @@
+package carpet
+
+import (
+	"encoding/xml"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// SonarRenderer - renders coverage of lines in SonarQube generic test coverage format,
+// with RenderOptions.Funcs only lines of these functions
+type SonarRenderer struct {
+	// Root - paths of files are relative to the root (usually base directory of project), absolute paths if empty
+	Root string
+
+	report     sonarCoverage
+	file       FileCover
+	result     sonarFile // lines of current file
+	lines      []LineStat
+	withRanges bool // lines are collected by ranges, without source (RenderOptions.WithoutSource) all lines are collected in EndFile
+}
+
+type sonarCoverage struct {
+	XMLName xml.Name    `xml:"coverage"`
+	Version string      `xml:"version,attr"`
+	Files   []sonarFile `xml:"file"`
+}
+
+type sonarFile struct {
+	Path  string      `xml:"path,attr"`
+	Lines []sonarLine `xml:"lineToCover"`
+}
+
+type sonarLine struct {
+	LineNumber int  `xml:"lineNumber,attr"`
+	Covered    bool `xml:"covered,attr"`
+}
+
+// BeginReport - start new report
+func (renderer *SonarRenderer) BeginReport(io.Writer) error {
+	renderer.report = sonarCoverage{Version: "1"}
+	return nil
+}
+
+// BeginFile - start file, coverage of lines is collected by ranges of functions
+func (renderer *SonarRenderer) BeginFile(_ io.Writer, file FileCover) error {
+	fileName := file.FileName
+	if renderer.Root != "" {
+		if relName, err := filepath.Rel(renderer.Root, fileName); err == nil && !strings.HasPrefix(relName, "..") {
+			fileName = relName
+		}
+	}
+
+	renderer.file, renderer.result = file, sonarFile{Path: filepath.ToSlash(fileName)}
+	renderer.lines, renderer.withRanges = GetLineStats(file), false
+	return nil
+}
+
+// addLines - collect coverage of lines from begin to end
+func (renderer *SonarRenderer) addLines(beginLine, endLine int) {
+	for _, line := range renderer.lines {
+		if line.Line >= beginLine && line.Line <= endLine {
+			renderer.result.Lines = append(renderer.result.Lines, sonarLine{LineNumber: line.Line, Covered: line.Covered})
+		}
+	}
+}
+
+// BeginRange - collect coverage of lines of function (or whole file), source is not rendered in SonarQube report
+func (renderer *SonarRenderer) BeginRange(_ io.Writer, textRange TextRange) error {
+	renderer.withRanges = true
+	beginLine, endLine := getRangeLines(renderer.file.Content, textRange)
+	renderer.addLines(beginLine, endLine)
+	return nil
+}
+
+// Segment - source is not rendered in SonarQube report
+func (renderer *SonarRenderer) Segment(io.Writer, Segment) error { return nil }
+
+// EndRange - source is not rendered in SonarQube report
+func (renderer *SonarRenderer) EndRange(io.Writer) error { return nil }
+
+// EndFile - add file to report, with all lines if source is not rendered
+func (renderer *SonarRenderer) EndFile(io.Writer) error {
+	if !renderer.withRanges {
+		renderer.addLines(0, math.MaxInt)
+	}
+
+	renderer.report.Files = append(renderer.report.Files, renderer.result)
+	return nil
+}
+
+// Summary - total coverage is calculated by SonarQube
+func (renderer *SonarRenderer) Summary(io.Writer, Summary) error { return nil }
+
+// EndReport - write XML document
+func (renderer *SonarRenderer) EndReport(w io.Writer) error {
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(renderer.report); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,141 @@
+package carpet
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// Coverage - get coverage of blocks in percent
+//
+// algorithms from Go-sources:
+//
+//	src/cmd/cover/html.go::percentCovered()
+//	src/testing/cover.go::coverReport()
+func Coverage(fileProfileBlocks []cover.ProfileBlock) (stat float64) {
+	total, covered := countStatements(fileProfileBlocks)
+	if total > 0 {
+		stat = float64(covered) / float64(total) * 100.0
+	}
+
+	return stat
+}
+
+func countStatements(fileProfileBlocks []cover.ProfileBlock) (total, covered int) {
+	for _, profileBlock := range fileProfileBlocks {
+		total += profileBlock.NumStmt
+		if profileBlock.Count > 0 {
+			covered += profileBlock.NumStmt
+		}
+	}
+
+	return total, covered
+}
+
+// FileStat - coverage of one file
+type FileStat struct {
+	FileName   string  `json:"file_name"` // file name from cover profile, without "_" prefix of local files
+	Package    string  `json:"package"`
+	Statements int     `json:"statements"`
+	Covered    int     `json:"covered"`
+	Coverage   float64 `json:"coverage"` // in percent
+}
+
+// GetFileStats - get coverage of each file
+func GetFileStats(files []FileCover) []FileStat {
+	result := make([]FileStat, 0, len(files))
+	for _, file := range files {
+		fileName := strings.TrimLeft(file.Profile.FileName, "_")
+		total, covered := countStatements(file.Profile.Blocks)
+		result = append(result, FileStat{
+			FileName:   fileName,
+			Package:    path.Dir(fileName),
+			Statements: total,
+			Covered:    covered,
+			Coverage:   file.Coverage(),
+		})
+	}
+
+	return result
+}
+
+// LineRange - range of lines in source file, from 1
+type LineRange struct {
+	Begin, End int
+}
+
+func (r LineRange) String() string {
+	if r.Begin == r.End {
+		return strconv.Itoa(r.Begin)
+	}
+	return fmt.Sprintf("%d-%d", r.Begin, r.End)
+}
+
+// UncoveredLineRanges - get sorted line ranges of not covered blocks, overlapped and adjacent ranges are joined
+func UncoveredLineRanges(fileProfileBlocks []cover.ProfileBlock) (result []LineRange) {
+	ranges := []LineRange{}
+	for _, profileBlock := range fileProfileBlocks {
+		if profileBlock.Count == 0 && profileBlock.NumStmt > 0 {
+			ranges = append(ranges, LineRange{Begin: profileBlock.StartLine, End: profileBlock.EndLine})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Begin < ranges[j].Begin })
+
+	for _, item := range ranges {
+		if last := len(result) - 1; last >= 0 && item.Begin <= result[last].End+1 {
+			if item.End > result[last].End {
+				result[last].End = item.End
+			}
+			continue
+		}
+		result = append(result, item)
+	}
+
+	return result
+}
+
+// LineStat - coverage of one line of source file
+type LineStat struct {
+	Line    int  `json:"line"` // from 1
+	Covered bool `json:"covered"`
+}
+
+// GetLineStats - get coverage of lines with statements, sorted by line, line is covered if any block on it is executed,
+// blocks are counted only on lines where they have code (not only spaces and braces)
+func GetLineStats(file FileCover) []LineStat {
+	lines := strings.Split(string(file.Content), "\n")
+	covered := map[int]bool{}
+	for _, block := range file.Profile.Blocks {
+		if block.NumStmt == 0 {
+			continue
+		}
+
+		for line := block.StartLine; line <= block.EndLine && line <= len(lines); line++ {
+			text := lines[line-1]
+			begin, end := 0, len(text)
+			if line == block.StartLine && block.StartCol > 1 {
+				begin = block.StartCol - 1
+			}
+			if line == block.EndLine && block.EndCol-1 < end {
+				end = block.EndCol - 1
+			}
+			if begin >= end || strings.Trim(text[begin:end], " \t{}") == "" {
+				continue
+			}
+
+			covered[line] = covered[line] || block.Count > 0
+		}
+	}
+
+	result := make([]LineStat, 0, len(covered))
+	for line, isCovered := range covered {
+		result = append(result, LineStat{Line: line, Covered: isCovered})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Line < result[j].Line })
+
+	return result
+}
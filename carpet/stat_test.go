@@ -0,0 +1,118 @@
+package carpet
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func Test_Coverage(t *testing.T) {
+	profileBlocks := []cover.ProfileBlock{
+		{
+			StartLine: 2,
+			StartCol:  5,
+			EndLine:   2,
+			EndCol:    10,
+			NumStmt:   1,
+			Count:     1,
+		},
+	}
+
+	stat := Coverage(profileBlocks)
+	if stat != 100.0 {
+		t.Errorf("1. Coverage() failed")
+	}
+
+	profileBlocks = append(profileBlocks,
+		cover.ProfileBlock{
+			StartLine: 3,
+			StartCol:  5,
+			EndLine:   3,
+			EndCol:    10,
+			NumStmt:   1,
+			Count:     0,
+		},
+	)
+	stat = Coverage(profileBlocks)
+	if stat != 50.0 {
+		t.Errorf("2. Coverage() failed")
+	}
+
+	profileBlocks = append(profileBlocks,
+		cover.ProfileBlock{
+			StartLine: 4,
+			StartCol:  5,
+			EndLine:   4,
+			EndCol:    10,
+			NumStmt:   1,
+			Count:     0,
+		},
+		cover.ProfileBlock{
+			StartLine: 4,
+			StartCol:  5,
+			EndLine:   4,
+			EndCol:    10,
+			NumStmt:   1,
+			Count:     0,
+		},
+	)
+	stat = Coverage(profileBlocks)
+	if stat != 25.0 {
+		t.Errorf("3. Coverage() failed")
+	}
+}
+
+func Test_UncoveredLineRanges(t *testing.T) {
+	blocks := []cover.ProfileBlock{
+		{StartLine: 10, EndLine: 12, NumStmt: 2, Count: 0},
+		{StartLine: 2, EndLine: 3, NumStmt: 1, Count: 0},
+		{StartLine: 4, EndLine: 4, NumStmt: 1, Count: 0},
+		{StartLine: 5, EndLine: 8, NumStmt: 3, Count: 1},
+		{StartLine: 11, EndLine: 15, NumStmt: 3, Count: 0},
+		{StartLine: 20, EndLine: 20, NumStmt: 0, Count: 0},
+	}
+
+	got := UncoveredLineRanges(blocks)
+	want := []LineRange{{Begin: 2, End: 4}, {Begin: 10, End: 15}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UncoveredLineRanges() = %v, want %v", got, want)
+	}
+
+	if got[0].String() != "2-4" || (LineRange{Begin: 7, End: 7}).String() != "7" {
+		t.Errorf("LineRange.String() failed")
+	}
+}
+
+func Test_GetFileStats(t *testing.T) {
+	files := []FileCover{{
+		Profile: &cover.Profile{FileName: "example.com/pkg/file.go", Blocks: []cover.ProfileBlock{
+			{StartLine: 2, EndLine: 3, NumStmt: 3, Count: 1},
+			{StartLine: 4, EndLine: 4, NumStmt: 1, Count: 0},
+		}},
+	}}
+
+	got := GetFileStats(files)
+	want := []FileStat{{FileName: "example.com/pkg/file.go", Package: "example.com/pkg", Statements: 4, Covered: 3, Coverage: 75}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetFileStats() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_GetLineStats(t *testing.T) {
+	file := FileCover{
+		Profile: &cover.Profile{FileName: "a.go", Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 19, EndLine: 4, EndCol: 11, NumStmt: 1, Count: 1},
+			{StartLine: 4, StartCol: 11, EndLine: 7, EndCol: 3, NumStmt: 1, Count: 0},
+			{StartLine: 7, StartCol: 3, EndLine: 8, EndCol: 10, NumStmt: 1, Count: 1},
+			{StartLine: 8, StartCol: 10, EndLine: 8, EndCol: 18, NumStmt: 1, Count: 0},
+		}},
+		Content: []byte("package a\n\nfunc a(x int) int {\n\tif x > 0 {\n\n\t\treturn 1\n\t}\n\treturn f(x) || 0\n}\n"),
+	}
+
+	got := GetLineStats(file)
+	want := []LineStat{{Line: 4, Covered: true}, {Line: 6, Covered: false}, {Line: 8, Covered: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetLineStats() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mgutz/ansi"
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+// compareState - transition of coverage of block from old to new profile, in order of priority for marker of line
+type compareState int
+
+const (
+	compareNone compareState = iota
+	compareStillCovered
+	compareStillUncovered
+	compareNewlyCovered
+	compareNewlyUncovered
+)
+
+// compareMarkers - markers of lines in plain output of compare
+var compareMarkers = map[compareState]string{
+	compareNone:           carpet.PlainMarkerNone,
+	compareStillCovered:   carpet.PlainMarkerCovered,
+	compareStillUncovered: carpet.PlainMarkerUncovered,
+	compareNewlyCovered:   "+!",
+	compareNewlyUncovered: "-!",
+}
+
+// getBlockTransitions - transition of each block of new profile, blocks are matched by position,
+// block which is not found in old profile is not covered in it
+func getBlockTransitions(newBlocks, oldBlocks []cover.ProfileBlock) []compareState {
+	oldCounts := make(map[carpet.BlockPos]int, len(oldBlocks))
+	for _, block := range oldBlocks {
+		oldCounts[carpet.GetBlockPos(block)] += block.Count
+	}
+
+	result := make([]compareState, len(newBlocks))
+	for i, block := range newBlocks {
+		wasCovered := oldCounts[carpet.GetBlockPos(block)] > 0
+		switch {
+		case block.Count > 0 && wasCovered:
+			result[i] = compareStillCovered
+		case block.Count > 0:
+			result[i] = compareNewlyCovered
+		case wasCovered:
+			result[i] = compareNewlyUncovered
+		default:
+			result[i] = compareStillUncovered
+		}
+	}
+
+	return result
+}
+
+// getCompareStates - transition of coverage of each byte of source
+func getCompareStates(file carpet.FileCover, oldBlocks []cover.ProfileBlock) []compareState {
+	lineOffsets := []int{0}
+	for i, char := range file.Content {
+		if char == '\n' {
+			lineOffsets = append(lineOffsets, i+1)
+		}
+	}
+	getOffset := func(line, col int) int {
+		if line < 1 || line > len(lineOffsets) {
+			return len(file.Content)
+		}
+		if offset := lineOffsets[line-1] + col - 1; offset < len(file.Content) {
+			return offset
+		}
+		return len(file.Content)
+	}
+
+	result := make([]compareState, len(file.Content))
+	for i, state := range getBlockTransitions(file.Profile.Blocks, oldBlocks) {
+		block := file.Profile.Blocks[i]
+		for offset := getOffset(block.StartLine, block.StartCol); offset < getOffset(block.EndLine, block.EndCol); offset++ {
+			result[offset] = state
+		}
+	}
+
+	return result
+}
+
+// compareRenderer - renders source of new profile with colors of transitions of coverage from old profile
+type compareRenderer struct {
+	config Config
+	th     theme
+	old    coverBaseline
+	states []compareState
+	colors colorSegments
+	line   []byte
+	marker compareState // the most important transition in current line for plain output
+}
+
+func (r *compareRenderer) BeginReport(io.Writer) error { return nil }
+
+func (r *compareRenderer) BeginFile(w io.Writer, file carpet.FileCover) error {
+	r.states = getCompareStates(file, r.old[file.Profile.FileName])
+
+	header := strings.TrimLeft(file.Profile.FileName, "_") + " - " +
+		formatCompareCoverage(r.old.getStat(file.Profile.FileName)) + fmt.Sprintf(" -> %.1f%%", file.Coverage())
+	_, err := io.WriteString(w, getColorHeader(header, true, r.config))
+	return err
+}
+
+func (r *compareRenderer) BeginRange(_ io.Writer, textRange carpet.TextRange) error {
+	r.colors.beginRange(textRange)
+	r.line, r.marker = r.line[:0], compareNone
+	return nil
+}
+
+func (r *compareRenderer) Segment(w io.Writer, segment carpet.Segment) error {
+	state := compareNone
+	if segment.Offset < len(r.states) {
+		state = r.states[segment.Offset]
+	}
+
+	if r.config.plain {
+		return r.plainSegment(w, segment.Text, state)
+	}
+
+	return r.colors.write(w, segment, r.getColor(state))
+}
+
+// getColor - changed coverage is shown with background color
+func (r *compareRenderer) getColor(state compareState) string {
+	switch state {
+	case compareStillCovered:
+		return colorCode(r.th.Covered.get(r.config.colors256, r.config.trueColor))
+	case compareStillUncovered:
+		return colorCode(r.th.Uncovered.get(r.config.colors256, r.config.trueColor))
+	case compareNewlyCovered:
+		return colorCode("default:" + r.th.CoveredBg.get(r.config.colors256, r.config.trueColor))
+	case compareNewlyUncovered:
+		return colorCode("default:" + r.th.UncoveredBg.get(r.config.colors256, r.config.trueColor))
+	}
+
+	return ""
+}
+
+// plainSegment - collect line, marker of line is the most important transition in line
+func (r *compareRenderer) plainSegment(w io.Writer, text []byte, state compareState) error {
+	for _, char := range text {
+		if char == '\n' {
+			if err := r.writePlainLine(w); err != nil {
+				return err
+			}
+			continue
+		}
+
+		r.line = append(r.line, char)
+		if state > r.marker {
+			r.marker = state
+		}
+	}
+
+	return nil
+}
+
+func (r *compareRenderer) writePlainLine(w io.Writer) error {
+	result := []byte{}
+	if r.marker != compareNone || len(r.line) > 0 {
+		result = append(result, compareMarkers[r.marker]...)
+	}
+	result = append(append(result, r.line...), '\n')
+	r.line, r.marker = r.line[:0], compareNone
+
+	_, err := w.Write(result)
+	return err
+}
+
+func (r *compareRenderer) EndRange(w io.Writer) error {
+	if r.config.plain {
+		return r.writePlainLine(w)
+	}
+
+	_, err := io.WriteString(w, ansi.ColorCode("reset")+"\n")
+	return err
+}
+
+func (r *compareRenderer) EndFile(io.Writer) error { return nil }
+
+func (r *compareRenderer) Summary(io.Writer, carpet.Summary) error { return nil }
+
+func (r *compareRenderer) EndReport(io.Writer) error { return nil }
+
+// fileCompare - change of coverage of one file
+type fileCompare struct {
+	fileName       string
+	oldCoverage    float64
+	inOld          bool
+	newCoverage    float64
+	newlyCovered   int // statements
+	newlyUncovered int
+}
+
+// getFileCompares - changes of coverage of files, files without changes are skipped
+func getFileCompares(files []carpet.FileCover, old coverBaseline) []fileCompare {
+	result := []fileCompare{}
+	for _, file := range files {
+		item := fileCompare{fileName: strings.TrimLeft(file.Profile.FileName, "_"), newCoverage: file.Coverage()}
+		item.oldCoverage, item.inOld = old.getStat(file.Profile.FileName)
+		for i, state := range getBlockTransitions(file.Profile.Blocks, old[file.Profile.FileName]) {
+			switch state {
+			case compareNewlyCovered:
+				item.newlyCovered += file.Profile.Blocks[i].NumStmt
+			case compareNewlyUncovered:
+				item.newlyUncovered += file.Profile.Blocks[i].NumStmt
+			}
+		}
+
+		if item.newlyCovered > 0 || item.newlyUncovered > 0 || !item.inOld || item.oldCoverage != item.newCoverage {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+// getCompareReport - table with changes of coverage of files and total coverage of both profiles
+func getCompareReport(files []carpet.FileCover, old coverBaseline, config Config) string {
+	compares := getFileCompares(files, old)
+
+	oldBlocks := []cover.ProfileBlock{}
+	for _, blocks := range old {
+		oldBlocks = append(oldBlocks, blocks...)
+	}
+	total := fmt.Sprintf("Coverage: %.1f%% -> %.1f%% of statements", carpet.Coverage(oldBlocks), carpet.GetSummary(files).Coverage)
+
+	if len(compares) == 0 {
+		return "\n" + getColorHeader(total+", no changes", false, config)
+	}
+
+	fileWidth := len("File")
+	for _, item := range compares {
+		if len(item.fileName) > fileWidth {
+			fileWidth = len(item.fileName)
+		}
+	}
+
+	header := fmt.Sprintf("%-*s %7s %7s %8s %13s %15s", fileWidth, "File", "old", "new", "delta", "newly covered", "newly uncovered")
+	result := "\n" + getColorHeader("Coverage changes:", false, config) + getColorHeader(header, true, config)
+	newlyCovered, newlyUncovered := 0, 0
+	for _, item := range compares {
+		delta := "new"
+		if item.inOld {
+			delta = fmt.Sprintf("%+.1f%%", item.newCoverage-item.oldCoverage)
+		}
+		result += fmt.Sprintf("%-*s %7s %6.1f%% %8s %13d %15d\n", fileWidth, item.fileName,
+			formatCompareCoverage(item.oldCoverage, item.inOld), item.newCoverage, delta, item.newlyCovered, item.newlyUncovered)
+		newlyCovered += item.newlyCovered
+		newlyUncovered += item.newlyUncovered
+	}
+
+	return result + getColorHeader(fmt.Sprintf("%s, newly covered: %d, newly uncovered: %d statements", total, newlyCovered, newlyUncovered), false, config)
+}
+
+// filterCoverBaseline - files of old profile for the same filter of files as new profile: shown files of new profile
+// and files which are matched by filter (for files removed in new profile)
+func filterCoverBaseline(old coverBaseline, files []carpet.FileCover, filesFilter []string) coverBaseline {
+	if len(filesFilter) == 0 {
+		return old
+	}
+
+	result := coverBaseline{}
+	for _, file := range files {
+		if blocks, ok := old[file.Profile.FileName]; ok {
+			result[file.Profile.FileName] = blocks
+		}
+	}
+	for fileName, blocks := range old {
+		if isSliceInString(fileName, filesFilter) {
+			result[fileName] = blocks
+		}
+	}
+
+	return result
+}
+
+// formatCompareCoverage - coverage of file in old profile, "-" if file is not found in it
+func formatCompareCoverage(coverage float64, ok bool) string {
+	if !ok {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.1f%%", coverage)
+}
+
+// runCompare - "go-carpet compare" subcommand: show changes of coverage between two cover profiles
+func runCompare(args []string) error {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	config := Config{}
+	flags.StringVar(&config.filesFilterRaw, "file", "", "comma-separated list of `files` to show (default: all)")
+	flags.BoolVar(&config.summary, "summary", false, "only show changes of coverage of files")
+	flags.StringVar(&config.colorMode, "color", colorAuto, "use colors: auto, always, never")
+	flags.BoolVar(&config.colors256, "256colors", false, "use colors for 256-color terminal")
+	flags.StringVar(&config.themeRaw, "theme", defaultThemeName, "color theme: "+strings.Join(getThemeNames(), ", ")+" or path to JSON `file` with theme")
+	flags.Usage = func() {
+		fmt.Println("usage: go-carpet compare [options] old.out new.out")
+		fmt.Println("source is shown with coverage of new profile, markers of lines in plain output:")
+		fmt.Println(`"+" - covered, "-" - not covered, "+!" - newly covered, "-!" - newly not covered`)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("compare requires two cover profiles, got %d", flags.NArg())
+	}
+
+	th, err := loadTheme(config.themeRaw)
+	if err != nil {
+		return err
+	}
+	config.theme = &th
+	config.trueColor = isTrueColorTerminal()
+	if config.plain, err = isPlainOutput(config.colorMode); err != nil {
+		return err
+	}
+	config.filesFilter = grepEmptyStringSlice(strings.Split(config.filesFilterRaw, ","))
+
+	old, err := loadCoverBaseline(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	files, err := carpet.LoadFiles(flags.Arg(1), carpet.Filter{Files: config.filesFilter})
+	if err != nil {
+		return err
+	}
+	old = filterCoverBaseline(old, files, config.filesFilter)
+
+	result := &bytes.Buffer{}
+	if !config.summary {
+		renderer := &compareRenderer{config: config, th: th, old: old}
+		if err := carpet.RenderFiles(result, renderer, files, carpet.RenderOptions{}); err != nil {
+			return err
+		}
+	}
+	result.WriteString(getCompareReport(files, old, config))
+
+	_, err = getColorWriter().Write(result.Bytes())
+	return err
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+func Test_getBlockTransitions(t *testing.T) {
+	newBlocks := []cover.ProfileBlock{
+		{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 5, Count: 1},
+		{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 5, Count: 2},
+		{StartLine: 3, StartCol: 1, EndLine: 3, EndCol: 5, Count: 0},
+		{StartLine: 4, StartCol: 1, EndLine: 4, EndCol: 5, Count: 0},
+		{StartLine: 5, StartCol: 1, EndLine: 5, EndCol: 5, Count: 1},
+	}
+	oldBlocks := []cover.ProfileBlock{
+		{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 5, Count: 1},
+		{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 5, Count: 0},
+		{StartLine: 3, StartCol: 1, EndLine: 3, EndCol: 5, Count: 3},
+		{StartLine: 4, StartCol: 1, EndLine: 4, EndCol: 5, Count: 0},
+	}
+
+	got := getBlockTransitions(newBlocks, oldBlocks)
+	want := []compareState{compareStillCovered, compareNewlyCovered, compareNewlyUncovered, compareStillUncovered, compareNewlyCovered}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getBlockTransitions() = %v, want %v", got, want)
+	}
+}
+
+func Test_compareRenderer(t *testing.T) {
+	files := []carpet.FileCover{{
+		Profile: &cover.Profile{FileName: "p/a.go", Mode: "set", Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 19, EndLine: 4, EndCol: 11, NumStmt: 1, Count: 1},
+			{StartLine: 4, StartCol: 11, EndLine: 6, EndCol: 3, NumStmt: 1, Count: 1},
+			{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 10, NumStmt: 1, Count: 0},
+			{StartLine: 10, StartCol: 19, EndLine: 12, EndCol: 2, NumStmt: 1, Count: 0},
+		}},
+		Content: []byte("package p\n\nfunc a(x int) int {\n\tif x > 0 {\n\t\treturn 1\n\t}\n\treturn x\n}\n\nfunc b(x int) int {\n\treturn x\n}\n"),
+	}}
+	old := coverBaseline{"p/a.go": {
+		{StartLine: 3, StartCol: 19, EndLine: 4, EndCol: 11, NumStmt: 1, Count: 1},
+		{StartLine: 4, StartCol: 11, EndLine: 6, EndCol: 3, NumStmt: 1, Count: 0},
+		{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 10, NumStmt: 1, Count: 1},
+		{StartLine: 10, StartCol: 19, EndLine: 12, EndCol: 2, NumStmt: 1, Count: 0},
+	}}
+
+	config := Config{plain: true}
+	result := &bytes.Buffer{}
+	if err := carpet.RenderFiles(result, &compareRenderer{config: config, old: old}, files, carpet.RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "p/a.go - 50.0% -> 50.0%\n" +
+		"~~~~~~~~~~~~~~~~~~~~~~~\n" +
+		"  package p\n" +
+		"\n" +
+		"+ func a(x int) int {\n" +
+		"+!\tif x > 0 {\n" +
+		"+!\t\treturn 1\n" +
+		"+!\t}\n" +
+		"-!\treturn x\n" +
+		"  }\n" +
+		"\n" +
+		"- func b(x int) int {\n" +
+		"- \treturn x\n" +
+		"- }\n" +
+		"\n"
+	if got := result.String(); got != want {
+		t.Errorf("compareRenderer:\ngot :\n%s\nwant:\n%s", got, want)
+	}
+
+	wantReport := "\n" + getColorHeader("Coverage changes:", false, config) +
+		getColorHeader("File       old     new    delta newly covered newly uncovered", true, config) +
+		"p/a.go   50.0%   50.0%    +0.0%             1               1\n" +
+		getColorHeader("Coverage: 50.0% -> 50.0% of statements, newly covered: 1, newly uncovered: 1 statements", false, config)
+	if got := getCompareReport(files, old, config); got != wantReport {
+		t.Errorf("getCompareReport():\ngot :\n%s\nwant:\n%s", got, wantReport)
+	}
+
+	if got := getCompareReport(files, coverBaseline{"p/a.go": files[0].Profile.Blocks}, config); got != "\n"+getColorHeader("Coverage: 50.0% -> 50.0% of statements, no changes", false, config) {
+		t.Errorf("getCompareReport() without changes: %q", got)
+	}
+}
+
+func Test_filterCoverBaseline(t *testing.T) {
+	blocks := []cover.ProfileBlock{{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 5, NumStmt: 1, Count: 1}}
+	old := coverBaseline{"example.com/p/a.go": blocks, "example.com/p/b.go": blocks, "example.com/q/removed.go": blocks}
+	files := []carpet.FileCover{{FileName: "/src/p/a.go", Profile: &cover.Profile{FileName: "example.com/p/a.go"}}}
+
+	if got := filterCoverBaseline(old, files, nil); !reflect.DeepEqual(got, old) {
+		t.Errorf("filterCoverBaseline() without filter = %v", got)
+	}
+
+	got := filterCoverBaseline(old, files, []string{"a.go", "removed"})
+	want := coverBaseline{"example.com/p/a.go": blocks, "example.com/q/removed.go": blocks}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("filterCoverBaseline() = %v, want %v", got, want)
+	}
+}
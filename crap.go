@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/msoap/go-carpet/carpet"
+)
+
+// getFuncsRisk - get functions sorted by CRAP score
+func getFuncsRisk(files []carpet.FileCover) []carpet.FuncStat {
+	result := carpet.GetFuncStats(files)
+	sort.SliceStable(result, func(i, j int) bool { return result[i].CRAP > result[j].CRAP })
+
+	return result
+}
+
+// getRiskReport - top-N functions by CRAP score: complex and poorly covered functions first
+func getRiskReport(files []carpet.FileCover, limit int, config Config) string {
+	funcs := getFuncsRisk(files)
+	if len(funcs) > limit {
+		funcs = funcs[:limit]
+	}
+	if len(funcs) == 0 {
+		return ""
+	}
+
+	positions := make([]string, 0, len(funcs))
+	positionWidth := len("Function")
+	for _, fn := range funcs {
+		position := fmt.Sprintf("%s:%d %s", fn.FileName, fn.StartLine, fn.Name)
+		positions = append(positions, position)
+		if len(position) > positionWidth {
+			positionWidth = len(position)
+		}
+	}
+
+	header := fmt.Sprintf("%-*s %10s %8s %8s", positionWidth, "Function", "complexity", "coverage", "CRAP")
+	result := "\n" + getColorHeader(header, true, config)
+	for i, fn := range funcs {
+		result += fmt.Sprintf("%-*s %10d %7.1f%% %8.1f\n", positionWidth, positions[i], fn.Complexity, fn.Coverage, fn.CRAP)
+	}
+
+	return result
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+func Test_getFuncsRisk(t *testing.T) {
+	src := `package pkg
+
+func simple() int {
+	return 1
+}
+
+func complex(a int) int {
+	if a > 0 {
+		return 1
+	}
+	return 0
+}
+
+type T struct{}
+`
+	files := []carpet.FileCover{{
+		Profile: &cover.Profile{FileName: "pkg/a.go", Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 19, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 0},
+			{StartLine: 7, StartCol: 25, EndLine: 8, EndCol: 11, NumStmt: 1, Count: 1},
+			{StartLine: 8, StartCol: 11, EndLine: 10, EndCol: 3, NumStmt: 1, Count: 0},
+			{StartLine: 11, StartCol: 2, EndLine: 11, EndCol: 10, NumStmt: 1, Count: 1},
+		}},
+		Content: []byte(src),
+	}}
+
+	got := getFuncsRisk(files)
+	if len(got) != 2 {
+		t.Fatalf("getFuncsRisk() = %v, want 2 functions", got)
+	}
+	if got[0].Name != "complex" || got[0].Complexity != 2 || got[0].Coverage < 66.6 || got[0].Coverage > 66.7 {
+		t.Errorf("getFuncsRisk()[0] = %+v", got[0])
+	}
+	if got[1].Name != "simple" || got[1].CRAP != 2 {
+		t.Errorf("getFuncsRisk()[1] = %+v", got[1])
+	}
+
+	report := getRiskReport(files, 1, Config{plain: true})
+	want := "\n" + getColorHeader("Function           complexity coverage     CRAP", true, Config{plain: true}) +
+		"pkg/a.go:7 complex          2    66.7%      2.1\n"
+	if report != want {
+		t.Errorf("getRiskReport():\ngot : %q\nwant: %q", report, want)
+	}
+}
@@ -15,12 +15,37 @@ Usage:
 	options:
 	    -256colors - use more colors on 256-color terminal (indicate the level of coverage)
 	    -args - pass additional arguments for go test (for example "-short" or "-i -timeout t")
+	    -badge file - write SVG badge with total coverage to file
+	    -badge-thresholds - coverage thresholds (in percent) for yellow and green color of badge (default "50,80")
+	    -baseline file - cover profile from previous run for show coverage changes (for markdown format)
+	    -blame N - show top N authors of not covered code by git blame
+	    -color - use colors: auto (without colors if NO_COLOR is set or output is not a terminal), always, never (default "auto")
+	    -covermode mode - cover mode for go test: set, count, atomic (default: count, atomic with -race in -args)
+	    -diff ref - annotate only not covered lines changed since git ref (for github format)
 	    -file string - comma-separated list of files to test (default: all)
+	    -fold - fold fully covered functions and long stretches of covered lines
+	    -format format - output format: terminal, markdown, github, html, json, plain, quickfix, sarif, sonar (default "terminal")
 	    -func string - comma-separated functions list (default: all functions)
+	    -heatmap N - show heatmap of execution counts on logarithmic scale and top N hottest blocks
 	    -include-vendor - include vendor directories for show coverage (Godeps, vendor)
+	    -record file - append git commit, total and per-package coverage to history file (JSON lines)
+	    -risk N - show top N risky functions by CRAP score (high complexity and low coverage)
+	    -serve address - serve coverage in browser on address (like ":8080" for localhost), tests are re-run on change of files
 	    -summary - only show summary for each file
+	    -syntax - highlight syntax and show coverage with background color
+	    -tests regexp - run each top-level test matched by regexp separately and show which tests cover code
+	    -tests-line file.go:line - with -tests: show tests which executed the line
+	    -tests-only test - with -tests: show coverage only by one test
+	    -theme - color theme: colorblind, dark, high-contrast, light or path to JSON file with theme (default "dark")
 	    -version - get version
 
+	go-carpet lsp [-profile file]
+	    language server over stdio, publishes coverage from cover profile (default "cover.out" in workspace root)
+	go-carpet history [-file history.jsonl] [-n N] [packages]
+	    show sparklines of coverage over time from history file recorded with -record option
+	go-carpet compare [-file files] [-summary] [-color mode] [-theme name] old.out new.out
+	    show source with changes of coverage of blocks (newly covered, newly not covered) and coverage changes of files
+
 Source: https://github.com/msoap/go-carpet
 */
 package main
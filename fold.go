@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/msoap/go-carpet/carpet"
+)
+
+// foldMinLines - minimal length of covered stretch of lines for folding
+const foldMinLines = 10
+
+// foldRange - lines of source which are replaced by one line with label, offsets from begin of line to end of last line
+type foldRange struct {
+	begin, end int
+	label      string
+}
+
+// foldRenderer - renders source with folded fully covered functions and long covered stretches of lines
+type foldRenderer struct {
+	carpet.Renderer
+	file  carpet.FileCover
+	folds []foldRange
+	next  int // index of the first fold which is not rendered yet
+}
+
+func (r *foldRenderer) BeginFile(w io.Writer, file carpet.FileCover) error {
+	r.file, r.folds, r.next = file, getFoldRanges(file), 0
+	return r.Renderer.BeginFile(w, file)
+}
+
+// Segment - render parts of segment out of folds, and label instead of each fold
+func (r *foldRenderer) Segment(w io.Writer, segment carpet.Segment) error {
+	pos, end := segment.Offset, segment.Offset+len(segment.Text)
+	if pos == end {
+		if r.next < len(r.folds) && pos > r.folds[r.next].begin {
+			// empty segment in folded lines
+			return nil
+		}
+		return r.Renderer.Segment(w, segment)
+	}
+
+	for pos < end {
+		if r.next < len(r.folds) && pos >= r.folds[r.next].begin {
+			fold := r.folds[r.next]
+			if pos == fold.begin {
+				// offset out of source: label is not highlighted
+				label := carpet.Segment{Text: []byte(fold.label), Offset: len(r.file.Content), State: carpet.CoverNone}
+				if err := r.Renderer.Segment(w, label); err != nil {
+					return err
+				}
+			}
+			if fold.end > end {
+				return nil
+			}
+			pos = fold.end
+			r.next++
+			continue
+		}
+
+		partEnd := end
+		if r.next < len(r.folds) && r.folds[r.next].begin < end {
+			partEnd = r.folds[r.next].begin
+		}
+		part := segment
+		part.Offset, part.Text = pos, segment.Text[pos-segment.Offset:partEnd-segment.Offset]
+		if err := r.Renderer.Segment(w, part); err != nil {
+			return err
+		}
+		pos = partEnd
+	}
+
+	return nil
+}
+
+// getFoldRanges - fully covered functions and long stretches of covered lines, sorted by offset
+func getFoldRanges(file carpet.FileCover) []foldRange {
+	lineOffsets := []int{0}
+	for i, char := range file.Content {
+		if char == '\n' {
+			lineOffsets = append(lineOffsets, i+1)
+		}
+	}
+	linesCount := len(lineOffsets)
+	lineEnd := func(line int) int {
+		if line < linesCount {
+			return lineOffsets[line]
+		}
+		return len(file.Content)
+	}
+
+	// 0 - line without code, 1 - covered, -1 - has not covered code or it is not foldable
+	lineStates := make([]int, linesCount+1)
+	states := carpet.CoverStates(file.Profile.Boundaries(file.Content), len(file.Content))
+	for line := 1; line <= linesCount; line++ {
+		for offset := lineOffsets[line-1]; offset < lineEnd(line); offset++ {
+			if isSpace(file.Content[offset]) {
+				continue
+			}
+			switch {
+			case states[offset] == carpet.CoverUncovered:
+				lineStates[line] = -1
+			case states[offset] == carpet.CoverCovered && lineStates[line] == 0:
+				lineStates[line] = 1
+			}
+		}
+	}
+
+	folds := map[int]foldRange{} // by begin line
+	for _, fn := range carpet.GetFuncStats([]carpet.FileCover{file}) {
+		// declarations of functions are not folded into covered stretches
+		lineStates[fn.StartLine] = -1
+		if fn.Coverage < 100 || fn.EndLine > linesCount {
+			continue
+		}
+
+		statements := 0
+		for _, block := range file.Profile.Blocks {
+			if block.StartLine >= fn.StartLine && block.EndLine <= fn.EndLine {
+				statements += block.NumStmt
+			}
+		}
+
+		firstLine := string(file.Content[lineOffsets[fn.StartLine-1]:lineEnd(fn.StartLine)])
+		indent := firstLine[:len(firstLine)-len(strings.TrimLeft(firstLine, " \t"))]
+		folds[fn.StartLine] = foldRange{
+			begin: lineOffsets[fn.StartLine-1],
+			end:   lineEnd(fn.EndLine),
+			label: fmt.Sprintf("%sfunc %s ... [covered, %d stmts]\n", indent, fn.Name, statements),
+		}
+		for line := fn.StartLine; line <= fn.EndLine; line++ {
+			lineStates[line] = -1
+		}
+	}
+
+	// stretches of covered lines and lines without code between them
+	for line := 1; line <= linesCount; {
+		if lineStates[line] != 1 {
+			line++
+			continue
+		}
+
+		first, last := line, line
+		for next := line + 1; next <= linesCount && lineStates[next] >= 0; next++ {
+			if lineStates[next] == 1 {
+				last = next
+			}
+		}
+		if last-first+1 >= foldMinLines {
+			folds[first] = foldRange{
+				begin: lineOffsets[first-1],
+				end:   lineEnd(last),
+				label: fmt.Sprintf("... %d covered lines ...\n", last-first+1),
+			}
+		}
+		line = last + 1
+	}
+
+	result := make([]foldRange, 0, len(folds))
+	for line := 1; line <= linesCount; line++ {
+		if fold, ok := folds[line]; ok {
+			result = append(result, fold)
+		}
+	}
+
+	return result
+}
+
+func isSpace(char byte) bool {
+	return bytes.IndexByte([]byte(" \t\r\n"), char) >= 0
+}
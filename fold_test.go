@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+func Test_foldRenderer(t *testing.T) {
+	files := []carpet.FileCover{{
+		Profile: &cover.Profile{FileName: "p/a.go", Mode: "count", Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 1},
+			{StartLine: 7, StartCol: 19, EndLine: 18, EndCol: 12, NumStmt: 11, Count: 1},
+			{StartLine: 18, StartCol: 12, EndLine: 20, EndCol: 3, NumStmt: 1, Count: 0},
+			{StartLine: 21, StartCol: 2, EndLine: 21, EndCol: 10, NumStmt: 1, Count: 1},
+		}},
+		Content: []byte("package p\n\nfunc a() int {\n\treturn 1\n}\n\nfunc b(x int) int {\n" +
+			strings.Repeat("\tx++\n", 10) + "\tif x > 100 {\n\t\treturn 0\n\t}\n\treturn x\n}\n"),
+	}}
+
+	got, _ := renderFilesCover(files, Config{plain: true, fold: true})
+	want := "p/a.go - 92.9%\n" +
+		"~~~~~~~~~~~~~~\n" +
+		"  package p\n" +
+		"\n" +
+		"  func a ... [covered, 1 stmts]\n" +
+		"\n" +
+		"+ func b(x int) int {\n" +
+		"  ... 10 covered lines ...\n" +
+		"- \tif x > 100 {\n" +
+		"- \t\treturn 0\n" +
+		"- \t}\n" +
+		"+ \treturn x\n" +
+		"  }\n" +
+		"\n"
+	if string(got) != want {
+		t.Errorf("renderFilesCover() with fold:\ngot :\n%s\nwant:\n%s", got, want)
+	}
+
+	// shorter stretch of covered lines is not folded
+	files[0].Content = []byte(strings.Replace(string(files[0].Content), "\tx++\n", "\n", 1))
+	if got, _ := renderFilesCover(files, Config{plain: true, fold: true}); strings.Contains(string(got), "covered lines") {
+		t.Errorf("renderFilesCover() folded short stretch:\n%s", got)
+	}
+
+	if got, _ := renderFilesCover(files, Config{plain: true, fold: true, funcFilter: []string{"a"}}); strings.Contains(string(got), "[covered") {
+		t.Errorf("renderFilesCover() folded with -func:\n%s", got)
+	}
+}
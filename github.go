@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/msoap/go-carpet/carpet"
+)
+
+// reGitDiffHunk - header of hunk in unified diff, with begin and length of range in new file
+var reGitDiffHunk = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// githubRenderer - renders not covered blocks as GitHub Actions workflow commands, which are shown as annotations in pull-requests
+type githubRenderer struct {
+	root    string                        // root of repository, paths in annotations are relative to it
+	changed map[string][]carpet.LineRange // changed lines by path from root, nil for annotate all not covered blocks
+}
+
+func (r *githubRenderer) BeginReport(io.Writer) error { return nil }
+
+func (r *githubRenderer) BeginFile(w io.Writer, file carpet.FileCover) error {
+	fileName, err := filepath.Rel(r.root, file.FileName)
+	if err != nil || strings.HasPrefix(fileName, "..") {
+		// file is out of repository
+		return nil
+	}
+	fileName = filepath.ToSlash(fileName)
+
+	for _, block := range file.Profile.Blocks {
+		if block.Count > 0 || block.NumStmt == 0 {
+			continue
+		}
+		if r.changed != nil && !isLinesChanged(r.changed[fileName], block.StartLine, block.EndLine) {
+			continue
+		}
+
+		message := fmt.Sprintf("%d statements are not covered by tests", block.NumStmt)
+		if _, err := fmt.Fprintf(w, "::warning file=%s,line=%d,endLine=%d,title=%s::%s\n",
+			escapeGitHubProperty(fileName), block.StartLine, block.EndLine, escapeGitHubProperty("Not covered"), escapeGitHubData(message),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *githubRenderer) BeginRange(io.Writer, carpet.TextRange) error { return nil }
+func (r *githubRenderer) Segment(io.Writer, carpet.Segment) error      { return nil }
+func (r *githubRenderer) EndRange(io.Writer) error                     { return nil }
+func (r *githubRenderer) EndFile(io.Writer) error                      { return nil }
+
+func (r *githubRenderer) Summary(w io.Writer, summary carpet.Summary) error {
+	_, err := fmt.Fprintf(w, "::notice title=Coverage::%s\n", escapeGitHubData(fmt.Sprintf("Coverage: %.1f%% of statements", summary.Coverage)))
+	return err
+}
+
+func (r *githubRenderer) EndReport(io.Writer) error { return nil }
+
+// writeGitHubReport - write annotations for GitHub Actions and markdown report to job summary ($GITHUB_STEP_SUMMARY)
+func writeGitHubReport(w io.Writer, files, deps []carpet.FileCover, failures []testFailure, baseline coverBaseline, config Config) error {
+	renderer := &githubRenderer{root: getGitRoot()}
+	if config.diffRef != "" {
+		diff, err := exec.Command("git", "-C", renderer.root, "diff", "-U0", "--no-color", "--no-ext-diff", config.diffRef, "--").Output() // #nosec
+		if err != nil {
+			return fmt.Errorf("failed to get git diff with %q: %w", config.diffRef, err)
+		}
+		renderer.changed = parseGitDiffLines(diff)
+	}
+
+	if err := carpet.Render(w, renderer, files, config.getRenderOptions()); err != nil {
+		return err
+	}
+	for _, failure := range failures {
+		if _, err := fmt.Fprintf(w, "::error title=Tests failed::%s\n", escapeGitHubData(fmt.Sprintf("%s: %s", failure.path, failure.err))); err != nil {
+			return err
+		}
+	}
+
+	summaryFileName := os.Getenv("GITHUB_STEP_SUMMARY")
+	if summaryFileName == "" {
+		return nil
+	}
+
+	summaryFile, err := os.OpenFile(summaryFileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) // #nosec
+	if err != nil {
+		return err
+	}
+	if err := writeMarkdownReport(summaryFile, files, deps, failures, baseline, config); err != nil {
+		_ = summaryFile.Close()
+		return err
+	}
+
+	return summaryFile.Close()
+}
+
+// getGitRoot - root of git repository, or current directory if it is not in repository
+func getGitRoot() string {
+	if out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output(); err == nil {
+		return strings.TrimSpace(string(out))
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+	return cwd
+}
+
+// parseGitDiffLines - changed (added or modified) lines by file name from output of "git diff -U0"
+func parseGitDiffLines(diff []byte) map[string][]carpet.LineRange {
+	result := map[string][]carpet.LineRange{}
+	fileName := ""
+	scanner := bufio.NewScanner(bytes.NewReader(diff))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			fileName = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+			if fileName == "/dev/null" {
+				fileName = ""
+			}
+		case strings.HasPrefix(line, "@@ ") && fileName != "":
+			match := reGitDiffHunk.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+
+			begin, _ := strconv.Atoi(match[1])
+			length := 1
+			if match[2] != "" {
+				length, _ = strconv.Atoi(match[2])
+			}
+			if length > 0 {
+				result[fileName] = append(result[fileName], carpet.LineRange{Begin: begin, End: begin + length - 1})
+			}
+		}
+	}
+
+	return result
+}
+
+// isLinesChanged - lines from begin to end are intersected with changed lines
+func isLinesChanged(changed []carpet.LineRange, begin, end int) bool {
+	for _, lineRange := range changed {
+		if begin <= lineRange.End && end >= lineRange.Begin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// escapeGitHubData - escape message of workflow command
+func escapeGitHubData(data string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(data)
+}
+
+// escapeGitHubProperty - escape property value of workflow command
+func escapeGitHubProperty(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(value)
+}
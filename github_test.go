@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+func Test_parseGitDiffLines(t *testing.T) {
+	diff := `diff --git a/pkg/a.go b/pkg/a.go
+index 1111111..2222222 100644
+--- a/pkg/a.go
++++ b/pkg/a.go
+@@ -3,0 +4,2 @@ func a() {
++	x := 1
++	y := 2
+@@ -10 +12 @@ func b() {
+-	return 0
++	return 1
+@@ -20,3 +21,0 @@ func c() {
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,3 +0,0 @@
+-package old
+`
+
+	want := map[string][]carpet.LineRange{
+		"pkg/a.go": {{Begin: 4, End: 5}, {Begin: 12, End: 12}},
+	}
+	if got := parseGitDiffLines([]byte(diff)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseGitDiffLines() = %v, want %v", got, want)
+	}
+}
+
+func Test_githubRenderer(t *testing.T) {
+	files := []carpet.FileCover{{
+		Profile: &cover.Profile{FileName: "example.com/repo/pkg/a.go", Mode: "count", Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 15, EndLine: 6, EndCol: 2, NumStmt: 2, Count: 0},
+			{StartLine: 8, StartCol: 15, EndLine: 10, EndCol: 2, NumStmt: 1, Count: 1},
+			{StartLine: 12, StartCol: 15, EndLine: 14, EndCol: 2, NumStmt: 1, Count: 0},
+		}},
+		FileName: "/src/repo/pkg/a.go",
+	}}
+
+	out := &bytes.Buffer{}
+	if err := carpet.Render(out, &githubRenderer{root: "/src/repo"}, files, carpet.RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "::warning file=pkg/a.go,line=3,endLine=6,title=Not covered::2 statements are not covered by tests\n" +
+		"::warning file=pkg/a.go,line=12,endLine=14,title=Not covered::1 statements are not covered by tests\n" +
+		"::notice title=Coverage::Coverage: 25.0%25 of statements\n"
+	if out.String() != want {
+		t.Errorf("githubRenderer:\ngot : %q\nwant: %q", out.String(), want)
+	}
+
+	// only changed lines
+	out.Reset()
+	renderer := &githubRenderer{root: "/src/repo", changed: map[string][]carpet.LineRange{"pkg/a.go": {{Begin: 14, End: 20}}}}
+	if err := carpet.RenderFiles(out, renderer, files, carpet.RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want = "::warning file=pkg/a.go,line=12,endLine=14,title=Not covered::1 statements are not covered by tests\n"
+	if out.String() != want {
+		t.Errorf("githubRenderer with diff:\ngot : %q\nwant: %q", out.String(), want)
+	}
+
+	// file out of repository
+	out.Reset()
+	if err := carpet.RenderFiles(out, &githubRenderer{root: "/other"}, files, carpet.RenderOptions{}); err != nil || out.Len() != 0 {
+		t.Errorf("githubRenderer for file out of repository: %q, %v", out.String(), err)
+	}
+}
+
+func Test_escapeGitHubProperty(t *testing.T) {
+	if got := escapeGitHubProperty("a,b:c%\n"); got != "a%2Cb%3Ac%25%0A" {
+		t.Errorf("escapeGitHubProperty() = %q", got)
+	}
+	if got := escapeGitHubData("a,b:c%\n"); got != "a,b:c%25%0A" {
+		t.Errorf("escapeGitHubData() = %q", got)
+	}
+}
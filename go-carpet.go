@@ -1,37 +1,49 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
-	"go/build"
-	"io"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
-	"runtime"
 	"strings"
 
 	"github.com/mgutz/ansi"
+	"github.com/msoap/go-carpet/carpet"
 	"golang.org/x/tools/cover"
 )
 
 const (
 	usageMessage = `go-carpet - show test coverage for Go source files
 
-usage: go-carpet [options] [paths]`
+usage: go-carpet [options] [paths]
+       go-carpet lsp [-profile file] - language server with coverage of cover profile
+       go-carpet history [-file history.jsonl] [-n N] [packages] - coverage over time recorded with -record option
+       go-carpet compare [options] old.out new.out - changes of coverage between two cover profiles`
 
 	version = "1.9.0"
 
 	// predefined go test options
 	goTestCoverProfile = "-coverprofile"
 	goTestCoverMode    = "-covermode"
+	goTestJSON         = "-json"
+	goTestRace         = "-race"
+
+	// cover modes
+	coverModeSet    = "set"
+	coverModeCount  = "count"
+	coverModeAtomic = "atomic"
+
+	// output formats
+	formatTerminal = "terminal"
+	formatMarkdown = "markdown"
+	formatGitHub   = "github"
 )
 
 var (
-	reNewLine        = regexp.MustCompile("\n")
-	reWindowsPathFix = regexp.MustCompile(`^_\\([A-Z])_`)
+	reNewLine = regexp.MustCompile("\n")
 
 	// vendors directories for skip
 	vendorDirs = []string{"Godeps", "vendor", ".vendor", "_vendor"}
@@ -39,7 +51,7 @@ var (
 	// directories for skip
 	skipDirs = []string{"testdata"}
 
-	errIsNotInGoMod = fmt.Errorf("is not in go modules")
+	outputFormats = append([]string{formatTerminal, formatMarkdown, formatGitHub}, carpet.RendererNames()...)
 )
 
 func getDirsWithTests(includeVendor bool, roots ...string) (result []string, err error) {
@@ -71,147 +83,101 @@ func getDirsWithTests(includeVendor bool, roots ...string) (result []string, err
 	return result, nil
 }
 
-func readFile(fileName string) (result []byte, err error) {
-	fileReader, err := os.Open(fileName)
-	if err != nil {
-		return result, err
-	}
-
-	result, err = io.ReadAll(fileReader)
-	if err == nil {
-		err = fileReader.Close()
-	}
-
-	return result, err
+/*
+Get all colors for 255-colors terminal:
+
+	gommand 'for i := 0; i < 256; i++ {fmt.Println(i, ansi.ColorCode(strconv.Itoa(i)) + "String" + ansi.ColorCode("reset"))}'
+*/
+var tenShadesOfGreen = [...]string{
+	"29",
+	"30",
+	"34",
+	"36",
+	"40",
+	"42",
+	"46",
+	"48",
+	"50",
+	"51",
 }
 
-func getShadeOfGreen(normCover float64) string {
-	/*
-		Get all colors for 255-colors terminal:
-			gommand 'for i := 0; i < 256; i++ {fmt.Println(i, ansi.ColorCode(strconv.Itoa(i)) + "String" + ansi.ColorCode("reset"))}'
-	*/
-	var tenShadesOfGreen = [...]string{
-		"29",
-		"30",
-		"34",
-		"36",
-		"40",
-		"42",
-		"46",
-		"48",
-		"50",
-		"51",
-	}
+// getShade - get one of shades for normalized coverage in [0, 1]
+func getShade(shades []string, normCover float64) string {
 	if normCover < 0 {
 		normCover = 0
 	}
 	if normCover > 1 {
 		normCover = 1
 	}
-	index := int((normCover - 0.00001) * float64(len(tenShadesOfGreen)))
-	return tenShadesOfGreen[index]
-}
-
-func runGoTest(path string, coverFileName string, goTestArgs []string, hideStderr bool) error {
-	args := []string{"test", goTestCoverProfile + "=" + coverFileName, goTestCoverMode + "=count"}
-	args = append(args, goTestArgs...)
-	args = append(args, path)
-	osExec := exec.Command("go", args...) // #nosec
-	if !hideStderr {
-		osExec.Stderr = os.Stderr
+	index := int((normCover - 0.00001) * float64(len(shades)))
+	if index < 0 {
+		index = 0
 	}
+	return shades[index]
+}
 
-	if output, err := osExec.Output(); err != nil {
-		fmt.Print(string(output))
-		return err
+// renderFilesCover - get colored source of files and all profile blocks,
+// heatmap is normalized by config.heatmapMax or by the maximum count in files if it is not set
+func renderFilesCover(files []carpet.FileCover, config Config) (result []byte, profileBlocks []cover.ProfileBlock) {
+	if config.heatmap > 0 && config.heatmapMax == 0 {
+		config.heatmapMax = getMaxCount(files)
 	}
 
-	return nil
-}
-
-func guessAbsPathInGOPATH(GOPATH, relPath string) (absPath string, err error) {
-	if GOPATH == "" {
-		GOPATH = build.Default.GOPATH
-		if GOPATH == "" {
-			return "", fmt.Errorf("GOPATH is not set")
-		}
+	var renderer carpet.Renderer = newTerminalRenderer(config)
+	if config.fold && len(config.funcFilter) == 0 {
+		renderer = &foldRenderer{Renderer: renderer}
 	}
 
-	gopathChunks := strings.Split(GOPATH, string(os.PathListSeparator))
-	for _, gopathChunk := range gopathChunks {
-		guessAbsPath := filepath.Join(gopathChunk, "src", relPath)
-		if _, err = os.Stat(guessAbsPath); err == nil {
-			absPath = guessAbsPath
-			break
-		}
+	buf := &bytes.Buffer{}
+	if err := carpet.RenderFiles(buf, renderer, files, config.getRenderOptions()); err != nil {
+		log.Print(err)
 	}
 
-	if absPath == "" {
-		return "", fmt.Errorf("file '%s' not found in GOPATH", relPath)
+	for _, file := range files {
+		profileBlocks = append(profileBlocks, file.Profile.Blocks...)
 	}
 
-	return absPath, err
+	return buf.Bytes(), profileBlocks
 }
 
-func getCoverForDir(coverFileName string, filesFilter []string, config Config) (result []byte, profileBlocks []cover.ProfileBlock, err error) {
-	coverProfile, err := cover.ParseProfiles(coverFileName)
-	if err != nil {
-		return result, profileBlocks, err
+// getDepsCover - separate section with coverage of third-party packages
+func getDepsCover(files []carpet.FileCover, config Config) string {
+	modules := []string{}
+	uniq := map[string]struct{}{}
+	for _, file := range files {
+		if _, ok := uniq[file.Dependency]; !ok {
+			uniq[file.Dependency] = struct{}{}
+			modules = append(modules, file.Dependency)
+		}
 	}
 
-	for _, fileProfile := range coverProfile {
-		// Skip files if minimal coverage is set and is covered more than minimal coverage
-		if config.minCoverage > 0 && config.minCoverage < 100.0 && getStatForProfileBlocks(fileProfile.Blocks) > config.minCoverage {
-			continue
-		}
+	result := "\n" + getColorHeader("Dependencies (third-party, read-only): "+strings.Join(modules, ", "), true, config)
+	coverInBytes, profileBlocks := renderFilesCover(files, config)
+	result += string(coverInBytes)
 
-		var fileName string
-		if strings.HasPrefix(fileProfile.FileName, "/") {
-			// TODO: what about windows?
-			fileName = fileProfile.FileName
-		} else if strings.HasPrefix(fileProfile.FileName, "_") {
-			// absolute path (or relative in tests)
-			if runtime.GOOS != "windows" {
-				fileName = strings.TrimLeft(fileProfile.FileName, "_")
-			} else {
-				// "_\C_\Users\..." -> "C:\Users\..."
-				fileName = reWindowsPathFix.ReplaceAllString(fileProfile.FileName, "$1:")
-			}
-		} else if fileName, err = guessAbsPathInGoMod(fileProfile.FileName); err != errIsNotInGoMod {
-			if err != nil {
-				return result, profileBlocks, err
-			}
-		} else {
-			// file in one dir in GOPATH
-			fileName, err = guessAbsPathInGOPATH(os.Getenv("GOPATH"), fileProfile.FileName)
-			if err != nil {
-				return result, profileBlocks, err
-			}
-		}
+	if len(config.funcFilter) == 0 {
+		stat := carpet.Coverage(profileBlocks)
+		result += getColorHeader(fmt.Sprintf("Dependencies coverage: %.1f%% of statements", stat), false, config)
+	}
 
-		if len(filesFilter) > 0 && !isSliceInString(fileName, filesFilter) {
-			continue
-		}
+	return result
+}
 
-		var fileBytes []byte
-		fileBytes, err = readFile(fileName)
-		if err != nil {
-			return result, profileBlocks, err
+func getColorHeader(header string, addUnderiline bool, config Config) string {
+	if config.plain {
+		result := header + "\n"
+		if addUnderiline {
+			result += strings.Repeat("~", len(header)) + "\n"
 		}
-
-		result = append(result, getCoverForFile(fileProfile, fileBytes, config)...)
-		profileBlocks = append(profileBlocks, fileProfile.Blocks...)
+		return result
 	}
 
-	return result, profileBlocks, err
-}
-
-func getColorHeader(header string, addUnderiline bool) string {
-	result := ansi.ColorCode("yellow") +
+	th := config.getTheme()
+	result := colorCode(th.Header.get(config.colors256, config.trueColor)) +
 		header + ansi.ColorCode("reset") + "\n"
 
 	if addUnderiline {
-		result += ansi.ColorCode("black+h") +
+		result += colorCode(th.Underline.get(config.colors256, config.trueColor)) +
 			strings.Repeat("~", len(header)) +
 			ansi.ColorCode("reset") + "\n"
 	}
@@ -219,162 +185,174 @@ func getColorHeader(header string, addUnderiline bool) string {
 	return result
 }
 
-// algorithms from Go-sources:
-//
-//	src/cmd/cover/html.go::percentCovered()
-//	src/testing/cover.go::coverReport()
-func getStatForProfileBlocks(fileProfileBlocks []cover.ProfileBlock) (stat float64) {
-	var total, covered int64
-	for _, profileBlock := range fileProfileBlocks {
-		total += int64(profileBlock.NumStmt)
-		if profileBlock.Count > 0 {
-			covered += int64(profileBlock.NumStmt)
-		}
-	}
-	if total > 0 {
-		stat = float64(covered) / float64(total) * 100.0
-	}
-
-	return stat
-}
-
-func getCoverForFile(fileProfile *cover.Profile, fileBytes []byte, config Config) (result []byte) {
-	stat := getStatForProfileBlocks(fileProfile.Blocks)
-
-	textRanges, err := getFileFuncRanges(fileBytes, config.funcFilter)
+func getTempFileName() (string, error) {
+	tmpFile, err := os.CreateTemp(".", "go-carpet-coverage-out-")
 	if err != nil {
-		return result
-	}
-
-	var fileNameDisplay string
-	if len(config.funcFilter) == 0 {
-		fileNameDisplay = fmt.Sprintf("%s - %.1f%%", strings.TrimLeft(fileProfile.FileName, "_"), stat)
-	} else {
-		fileNameDisplay = strings.TrimLeft(fileProfile.FileName, "_")
+		return "", err
 	}
-
-	if config.summary {
-		return []byte(fileNameDisplay + "\n")
+	err = tmpFile.Close()
+	if err != nil {
+		return "", err
 	}
 
-	result = append(result, []byte(getColorHeader(fileNameDisplay, true))...)
+	return tmpFile.Name(), nil
+}
 
-	boundaries := fileProfile.Boundaries(fileBytes)
+// Config - application config
+type Config struct {
+	filesFilterRaw string
+	filesFilter    []string
+	funcFilterRaw  string
+	funcFilter     []string
+	argsRaw        string
+	minCoverage    float64
+	colors256      bool
+	includeVendor  bool
+	summary        bool
+	syntax         bool
+	fold           bool
+	format         string
+	baselineFile   string
+	diffRef        string
+	themeRaw       string
+	theme          *theme
+	trueColor      bool
+	colorMode      string
+	plain          bool
+	coverMode      string
+	heatmap        int
+	heatmapMax     int
+	risk           int
+	blame          int
+	serve          string
+	testsPattern   string
+	testsLine      string
+	testsOnly      string
+	badgeFile      string
+	recordFile     string
+	badgeRaw       string
+	badge          badgeThresholds
+}
 
-	for _, textRange := range textRanges {
-		fileBytesPart := fileBytes[textRange.begin:textRange.end]
-		curOffset := 0
-		coverColor := ""
+// coverRun - result of one run of tests of all packages
+type coverRun struct {
+	files       []carpet.FileCover // own files of packages
+	deps        carpet.Merged
+	failures    []testFailure
+	attribution testsCover
+}
 
-		for _, boundary := range boundaries {
-			if boundary.Offset < textRange.begin || boundary.Offset > textRange.end {
-				// skip boundary which is not in filter function
+// runCover - run tests of each package and get coverage, onPackage is called with own files after tests of each package
+func runCover(testDirs []string, coverFileName string, goTestArgs []string, config Config, onPackage func(ownFiles []carpet.FileCover)) (result coverRun) {
+	for _, path := range testDirs {
+		var files []carpet.FileCover
+		if config.testsPattern != "" {
+			testsFiles, testsFailures, err := runTestsSeparately(path, coverFileName, goTestArgs, config, &result.attribution)
+			result.failures = append(result.failures, testsFailures...)
+			if err != nil {
+				log.Print(err)
 				continue
 			}
-
-			boundaryOffset := boundary.Offset - textRange.begin
-
-			if boundaryOffset > curOffset {
-				nextChunk := fileBytesPart[curOffset:boundaryOffset]
-				// Add ansi color code in begin of each line (this fixed view in "less -R")
-				if coverColor != "" && coverColor != ansi.ColorCode("reset") {
-					nextChunk = reNewLine.ReplaceAllLiteral(nextChunk, []byte(ansi.ColorCode("reset")+"\n"+coverColor))
-				}
-				result = append(result, nextChunk...)
+			files = testsFiles
+		} else {
+			if testResult, errTest := runGoTest(path, coverFileName, config.coverMode, goTestArgs, false); errTest != nil {
+				// show partial coverage if profile was written, failed packages are shown at the end
+				result.failures = append(result.failures, testFailure{path: path, err: errTest, result: testResult})
 			}
 
-			switch {
-			case boundary.Start && boundary.Count > 0:
-				coverColor = ansi.ColorCode("green")
-				if config.colors256 {
-					coverColor = ansi.ColorCode(getShadeOfGreen(boundary.Norm))
-				}
-			case boundary.Start && boundary.Count == 0:
-				coverColor = ansi.ColorCode("red")
-			case !boundary.Start:
-				coverColor = ansi.ColorCode("reset")
+			var err error
+			if files, err = carpet.LoadFiles(coverFileName, config.getFilter()); err != nil {
+				log.Print(err)
+				continue
 			}
-			result = append(result, []byte(coverColor)...)
-
-			curOffset = boundaryOffset
 		}
-		if curOffset < len(fileBytesPart) {
-			result = append(result, fileBytesPart[curOffset:]...)
+
+		ownFiles := []carpet.FileCover{}
+		for _, file := range files {
+			if file.Dependency != "" {
+				result.deps.Add(file)
+				continue
+			}
+			ownFiles = append(ownFiles, file)
 		}
 
-		result = append(result, []byte("\n")...)
+		result.files = append(result.files, ownFiles...)
+		if onPackage != nil {
+			onPackage(ownFiles)
+		}
 	}
 
 	return result
 }
 
-type textRange struct {
-	begin, end int
-}
-
-func getFileFuncRanges(fileBytes []byte, funcs []string) (result []textRange, err error) {
-	if len(funcs) == 0 {
-		return []textRange{{
-			begin: 0,
-			end:   len(fileBytes),
-		}}, nil
-	}
-
-	golangFuncs, err := getGolangFuncs(fileBytes)
-	if err != nil {
-		return nil, err
+// getCoverMode - check cover mode, by default "count" or "atomic" for tests with race detector (like go test does),
+// cover mode in arguments of go test is not allowed, because it is always set by go-carpet
+func getCoverMode(coverMode string, goTestArgs []string) (string, error) {
+	if isArgSet(goTestCoverMode, goTestArgs) {
+		return "", fmt.Errorf("%s in -args is not supported, use -covermode option of go-carpet", goTestCoverMode)
 	}
 
-	for _, existsFunc := range golangFuncs {
-		for _, filterFuncName := range funcs {
-			if existsFunc.Name == filterFuncName {
-				result = append(result, textRange{begin: existsFunc.Begin - 1, end: existsFunc.End - 1})
-			}
+	switch coverMode {
+	case coverModeSet, coverModeCount, coverModeAtomic:
+		return coverMode, nil
+	case "":
+		if isBoolArgSet(goTestRace, goTestArgs) {
+			return coverModeAtomic, nil
 		}
+		return coverModeCount, nil
+	default:
+		return "", fmt.Errorf("unknown cover mode: %q, use one of: %s, %s, %s", coverMode, coverModeSet, coverModeCount, coverModeAtomic)
 	}
+}
 
-	if len(result) == 0 {
-		return nil, fmt.Errorf("filter by functions: %v - not found", funcs)
-	}
-
-	return result, nil
+// getFilter - filter of files from cover profile by -file and -mincov options
+func (config Config) getFilter() carpet.Filter {
+	return carpet.Filter{Files: config.filesFilter, MinCoverage: config.minCoverage}
 }
 
-func getTempFileName() (string, error) {
-	tmpFile, err := os.CreateTemp(".", "go-carpet-coverage-out-")
-	if err != nil {
-		return "", err
-	}
-	err = tmpFile.Close()
-	if err != nil {
-		return "", err
+// getTheme - get color theme, default theme if it is not set
+func (config Config) getTheme() theme {
+	if config.theme == nil {
+		return themes[defaultThemeName]
 	}
 
-	return tmpFile.Name(), nil
-}
-
-// Config - application config
-type Config struct {
-	filesFilterRaw string
-	filesFilter    []string
-	funcFilterRaw  string
-	funcFilter     []string
-	argsRaw        string
-	minCoverage    float64
-	colors256      bool
-	includeVendor  bool
-	summary        bool
+	return *config.theme
 }
 
 var config Config
 
+// subcommands - commands with own options: go-carpet <command> [options]
+var subcommands = map[string]func(args []string) error{
+	"lsp":     runLSP,
+	"history": runHistory,
+	"compare": runCompare,
+}
+
 func init() {
 	flag.StringVar(&config.filesFilterRaw, "file", "", "comma-separated list of `files` to test (default: all)")
 	flag.StringVar(&config.funcFilterRaw, "func", "", "comma-separated `functions` list (default: all functions)")
 	flag.BoolVar(&config.colors256, "256colors", false, "use more colors on 256-color terminal (indicate the level of coverage)")
 	flag.BoolVar(&config.summary, "summary", false, "only show summary for each file")
+	flag.StringVar(&config.colorMode, "color", colorAuto, "use colors: auto (without colors if NO_COLOR is set or output is not a terminal), always, never")
+	flag.StringVar(&config.testsPattern, "tests", "", "run each top-level test matched by `regexp` separately and show which tests cover code")
+	flag.StringVar(&config.testsLine, "tests-line", "", "with -tests: show tests which executed the line (`file.go:line`)")
+	flag.StringVar(&config.testsOnly, "tests-only", "", "with -tests: show coverage only by one `test`")
+	flag.StringVar(&config.themeRaw, "theme", defaultThemeName, "color theme: "+strings.Join(getThemeNames(), ", ")+" or path to JSON `file` with theme")
+	flag.BoolVar(&config.syntax, "syntax", false, "highlight syntax and show coverage with background color")
+	flag.BoolVar(&config.fold, "fold", false, "fold fully covered functions and long stretches of covered lines")
 	flag.BoolVar(&config.includeVendor, "include-vendor", false, "include vendor directories for show coverage (Godeps, vendor)")
 	flag.StringVar(&config.argsRaw, "args", "", "pass additional `arguments` for go test")
+	flag.IntVar(&config.heatmap, "heatmap", 0, "show heatmap of execution counts on logarithmic scale and top `N` hottest blocks")
+	flag.IntVar(&config.risk, "risk", 0, "show top `N` risky functions by CRAP score (high complexity and low coverage)")
+	flag.IntVar(&config.blame, "blame", 0, "show top `N` authors of not covered code by git blame")
+	flag.StringVar(&config.serve, "serve", "", "serve coverage in browser on `address` (like \":8080\" for localhost), tests are re-run on change of files")
+	flag.StringVar(&config.coverMode, "covermode", "", "cover `mode` for go test: set, count, atomic (default: count, atomic with -race in -args)")
+	flag.StringVar(&config.format, "format", formatTerminal, "output `format`: "+strings.Join(outputFormats, ", "))
+	flag.StringVar(&config.baselineFile, "baseline", "", "cover profile `file` from previous run for show coverage changes (for markdown format)")
+	flag.StringVar(&config.diffRef, "diff", "", "annotate only not covered lines changed since git `ref` (for github format)")
+	flag.StringVar(&config.recordFile, "record", "", "append git commit, total and per-package coverage to history `file` (JSON lines)")
+	flag.StringVar(&config.badgeFile, "badge", "", "write SVG badge with total coverage to `file`")
+	flag.StringVar(&config.badgeRaw, "badge-thresholds", "50,80", "coverage `thresholds` (in percent) for yellow and green color of badge")
 	flag.Float64Var(&config.minCoverage, "mincov", 100.0, "coverage threshold of the file to be displayed (in percent)")
 	flag.Usage = func() {
 		fmt.Println(usageMessage)
@@ -384,6 +362,15 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := subcommands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
 	versionFl := flag.Bool("version", false, "get version")
 	flag.Parse()
 
@@ -394,26 +381,85 @@ func main() {
 
 	config.filesFilter = grepEmptyStringSlice(strings.Split(config.filesFilterRaw, ","))
 	config.funcFilter = grepEmptyStringSlice(strings.Split(config.funcFilterRaw, ","))
-	additionalArgs, err := parseAdditionalArgs(config.argsRaw, []string{goTestCoverProfile, goTestCoverMode})
+	if !isStringInSlice(config.format, outputFormats) {
+		log.Fatalf("unknown format: %q, use one of: %s", config.format, strings.Join(outputFormats, ", "))
+	}
+
+	th, err := loadTheme(config.themeRaw)
 	if err != nil {
 		log.Fatal(err)
 	}
+	config.theme = &th
+	config.trueColor = isTrueColorTerminal()
+	if config.plain, err = isPlainOutput(config.colorMode); err != nil {
+		log.Fatal(err)
+	}
 
-	testDirs := flag.Args()
+	if config.testsPattern == "" && (config.testsLine != "" || config.testsOnly != "") {
+		log.Fatal("-tests-line and -tests-only options require -tests option")
+	}
+	if config.testsLine != "" {
+		if _, _, err = parseFileLine(config.testsLine); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	coverFileName, err := getTempFileName()
+	if config.badge, err = parseBadgeThresholds(config.badgeRaw); err != nil {
+		log.Fatal(err)
+	}
+
+	allArgs, err := parseAdditionalArgs(config.argsRaw, nil)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer func() {
-		err = os.RemoveAll(coverFileName)
-		if err != nil {
+	if config.coverMode, err = getCoverMode(config.coverMode, allArgs); err != nil {
+		log.Fatal(err)
+	}
+	additionalArgs, err := parseAdditionalArgs(config.argsRaw, []string{goTestCoverProfile, goTestJSON})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if config.heatmap < 0 || config.risk < 0 || config.blame < 0 {
+		log.Fatal("-heatmap, -risk and -blame options must be positive numbers")
+	}
+	if config.heatmap > 0 && config.coverMode == coverModeSet {
+		log.Fatal("-heatmap option requires count or atomic cover mode")
+	}
+
+	if config.diffRef != "" && config.format != formatGitHub {
+		log.Fatal("-diff option requires -format github")
+	}
+
+	var baseline coverBaseline
+	if config.baselineFile != "" {
+		if baseline, err = loadCoverBaseline(config.baselineFile); err != nil {
 			log.Fatal(err)
 		}
-	}()
+	}
+
+	testDirs := flag.Args()
+
+	coverFileName, err := getTempFileName()
+	if err != nil {
+		log.Fatal(err)
+	}
 
+	failures, err := showCoverage(testDirs, coverFileName, additionalArgs, baseline)
+	// temporary cover profile is removed on errors too
+	if errRemove := os.RemoveAll(coverFileName); errRemove != nil && err == nil {
+		err = errRemove
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(failures) > 0 {
+		os.Exit(1)
+	}
+}
+
+// showCoverage - run tests of directories and show coverage in format from config, returns failed packages
+func showCoverage(testDirs []string, coverFileName string, goTestArgs []string, baseline coverBaseline) (failures []testFailure, err error) {
 	stdOut := getColorWriter()
-	allProfileBlocks := []cover.ProfileBlock{}
 
 	if len(testDirs) > 0 {
 		testDirs, err = getDirsWithTests(config.includeVendor, testDirs...)
@@ -421,34 +467,125 @@ func main() {
 		testDirs, err = getDirsWithTests(config.includeVendor, ".")
 	}
 	if err != nil {
-		log.Fatal(err)
+		return failures, err
 	}
 
-	for _, path := range testDirs {
-		if err = runGoTest(path, coverFileName, additionalArgs, false); err != nil {
-			log.Print(err)
-			continue
+	if config.serve != "" {
+		return failures, serve(config, testDirs, coverFileName, goTestArgs)
+	}
+
+	var errWrite error
+	run := runCover(testDirs, coverFileName, goTestArgs, config, func(ownFiles []carpet.FileCover) {
+		// heatmap of all packages is shown on the same scale after all tests
+		if config.format != formatTerminal || config.heatmap > 0 || errWrite != nil {
+			return
 		}
 
-		coverInBytes, profileBlocks, errCover := getCoverForDir(coverFileName, config.filesFilter, config)
-		if errCover != nil {
-			log.Print(errCover)
-			continue
+		coverInBytes, _ := renderFilesCover(ownFiles, config)
+		_, errWrite = stdOut.Write(coverInBytes)
+	})
+	allFiles, deps, attribution := run.files, run.deps, run.attribution
+	failures = run.failures
+	if errWrite != nil {
+		return failures, errWrite
+	}
+
+	if config.format == formatTerminal && config.heatmap > 0 {
+		config.heatmapMax = getMaxCount(append(append([]carpet.FileCover{}, allFiles...), deps.Files...))
+		coverInBytes, _ := renderFilesCover(allFiles, config)
+		if _, err := stdOut.Write(coverInBytes); err != nil {
+			return failures, err
+		}
+	}
+
+	summary := carpet.GetSummary(allFiles)
+	if config.badgeFile != "" {
+		if err = writeBadge(config.badgeFile, summary.Coverage, config.badge); err != nil {
+			return failures, err
+		}
+	}
+
+	if config.recordFile != "" {
+		if err = appendHistory(config.recordFile, newHistoryRecord(allFiles)); err != nil {
+			return failures, err
 		}
-		_, err = stdOut.Write(coverInBytes)
+	}
+
+	// terminal, markdown and github formats are not renderers, they show failed packages, dependencies and baseline
+	switch config.format {
+	case formatTerminal:
+		// files of packages are already written, summary and other sections are written below
+	case formatMarkdown:
+		return failures, writeMarkdownReport(os.Stdout, allFiles, deps.Files, failures, baseline, config)
+	case formatGitHub:
+		return failures, writeGitHubReport(os.Stdout, allFiles, deps.Files, failures, baseline, config)
+	default:
+		// output formats from carpet package or registered by third-party packages
+		renderer, errRenderer := carpet.NewRenderer(config.format)
+		if errRenderer != nil {
+			return failures, errRenderer
+		}
+		// paths in reports for dashboards are relative to the root of repository
+		switch renderer := renderer.(type) {
+		case *carpet.SARIFRenderer:
+			renderer.Root, renderer.ToolVersion = getGitRoot(), version
+		case *carpet.SonarRenderer:
+			renderer.Root = getGitRoot()
+		}
+		return failures, carpet.Render(os.Stdout, renderer, allFiles, config.getRenderOptions())
+	}
+
+	if len(allFiles) > 0 && len(config.funcFilter) == 0 {
+		if err = newTerminalRenderer(config).Summary(stdOut, summary); err != nil {
+			return failures, err
+		}
+	}
+
+	if len(deps.Files) > 0 {
+		_, err = stdOut.Write([]byte(getDepsCover(deps.Files, config)))
 		if err != nil {
-			log.Fatal(err)
+			return failures, err
+		}
+	}
+
+	if config.heatmap > 0 {
+		if _, err = stdOut.Write([]byte(getHeatmapReport(allFiles, config.heatmap, config))); err != nil {
+			return failures, err
 		}
+	}
+
+	if config.risk > 0 {
+		if _, err = stdOut.Write([]byte(getRiskReport(allFiles, config.risk, config))); err != nil {
+			return failures, err
+		}
+	}
+
+	if config.blame > 0 {
+		if _, err = stdOut.Write([]byte(getBlameReport(allFiles, config.blame, getGitBlame, config))); err != nil {
+			return failures, err
+		}
+	}
 
-		allProfileBlocks = append(allProfileBlocks, profileBlocks...)
+	if config.testsPattern != "" {
+		testsReport := getTestsReport(attribution, config)
+		if config.testsLine != "" {
+			lineReport, errLine := getTestsForLineReport(attribution, config.testsLine, config)
+			if errLine != nil {
+				return failures, errLine
+			}
+			testsReport += lineReport
+		}
+		if _, err = stdOut.Write([]byte(testsReport)); err != nil {
+			return failures, err
+		}
 	}
 
-	if len(allProfileBlocks) > 0 && len(config.funcFilter) == 0 {
-		stat := getStatForProfileBlocks(allProfileBlocks)
-		totalCoverage := fmt.Sprintf("Coverage: %.1f%% of statements", stat)
-		_, err = stdOut.Write([]byte(getColorHeader(totalCoverage, false)))
+	if len(failures) > 0 {
+		_, err = stdOut.Write([]byte(getFailuresReport(failures, config)))
 		if err != nil {
-			log.Fatal(err)
+			return failures, err
 		}
 	}
+
+	return failures, nil
 }
@@ -3,9 +3,11 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mgutz/ansi"
+	"github.com/msoap/go-carpet/carpet"
 	"golang.org/x/tools/cover"
 )
 
@@ -18,6 +20,12 @@ func assertDontPanic(t *testing.T, fn func(), name string) {
 	fn()
 }
 
+// renderFileCover - get colored source of one file
+func renderFileCover(fileProfile *cover.Profile, fileContent []byte, config Config) []byte {
+	result, _ := renderFilesCover([]carpet.FileCover{{Profile: fileProfile, Content: fileContent}}, config)
+	return result
+}
+
 // usage:
 //
 //	defer testChdir(t, "/path")()
@@ -39,35 +47,18 @@ func testChdir(t *testing.T, dir string) func() {
 	}
 }
 
-func Test_readFile(t *testing.T) {
-	file, err := readFile("go-carpet_test.go")
-	if err != nil {
-		t.Errorf("readFile(): got error: %s", err)
-	}
-	if len(file) == 0 {
-		t.Errorf("readFile(): file empty")
-	}
-	if string(file[:12]) != "package main" {
-		t.Errorf("readFile(): failed read first line")
-	}
-
-	_, err = readFile("dont exists file")
-	if err == nil {
-		t.Errorf("File exists error:")
-	}
-}
-
 func Test_getDirsWithTests(t *testing.T) {
 	dirs, err := getDirsWithTests(false, ".")
 	if len(dirs) == 0 || err != nil {
 		t.Errorf("getDirsWithTests(): dir list is empty")
 	}
+	dirsCount := len(dirs)
 	dirs, err = getDirsWithTests(false)
 	if len(dirs) == 0 || err != nil {
 		t.Errorf("getDirsWithTests(): dir list is empty")
 	}
 	dirs, err = getDirsWithTests(false, ".", ".")
-	if len(dirs) != 1 || err != nil {
+	if len(dirs) != dirsCount || err != nil {
 		t.Errorf("getDirsWithTests(): the same directory failed")
 	}
 
@@ -107,7 +98,7 @@ func Test_getTempFileName(t *testing.T) {
 	}
 }
 
-func Test_getShadeOfGreen(t *testing.T) {
+func Test_getShade(t *testing.T) {
 	testData := []struct {
 		normCover float64
 		result    string
@@ -143,7 +134,7 @@ func Test_getShadeOfGreen(t *testing.T) {
 	}
 
 	for i, item := range testData {
-		result := getShadeOfGreen(item.normCover)
+		result := getShade(tenShadesOfGreen[:], item.normCover)
 		if result != item.result {
 			t.Errorf("\n%d.\nexpected: %v\nreal    : %v", i, item.result, result)
 		}
@@ -155,7 +146,7 @@ func Test_getColorWriter(t *testing.T) {
 }
 
 func Test_getColorHeader(t *testing.T) {
-	result := getColorHeader("filename.go", true)
+	result := getColorHeader("filename.go", true, Config{})
 	expected := ansi.ColorCode("yellow") + "filename.go" + ansi.ColorCode("reset") + "\n" +
 		ansi.ColorCode("black+h") + "~~~~~~~~~~~" + ansi.ColorCode("reset") + "\n"
 
@@ -163,7 +154,7 @@ func Test_getColorHeader(t *testing.T) {
 		t.Errorf("1. getColorHeader() failed")
 	}
 
-	result = getColorHeader("filename.go", false)
+	result = getColorHeader("filename.go", false, Config{})
 	expected = ansi.ColorCode("yellow") + "filename.go" + ansi.ColorCode("reset") + "\n"
 
 	if result != expected {
@@ -171,7 +162,7 @@ func Test_getColorHeader(t *testing.T) {
 	}
 }
 
-func Test_getCoverForFile(t *testing.T) {
+func Test_renderFilesCover(t *testing.T) {
 	fileProfile := &cover.Profile{
 		FileName: "filename.go",
 		Mode:     "count",
@@ -188,13 +179,13 @@ func Test_getCoverForFile(t *testing.T) {
 	}
 	fileContent := []byte("1 line\n123 green 456\n3 line red and other")
 
-	coloredBytes := getCoverForFile(fileProfile, fileContent, Config{colors256: false})
-	expectOut := getColorHeader("filename.go - 100.0%", true) +
+	coloredBytes := renderFileCover(fileProfile, fileContent, Config{colors256: false})
+	expectOut := getColorHeader("filename.go - 100.0%", true, Config{}) +
 		"1 line\n" +
 		"123 " + ansi.ColorCode("green") + "green" + ansi.ColorCode("reset") + " 456\n" +
 		"3 line red and other\n"
 	if string(coloredBytes) != expectOut {
-		t.Errorf("1. getCoverForFile() failed")
+		t.Errorf("1. renderFilesCover() failed")
 	}
 
 	// with red blocks
@@ -208,125 +199,75 @@ func Test_getCoverForFile(t *testing.T) {
 			Count:     0,
 		},
 	)
-	coloredBytes = getCoverForFile(fileProfile, fileContent, Config{colors256: false})
-	expectOut = getColorHeader("filename.go - 100.0%", true) +
+	coloredBytes = renderFileCover(fileProfile, fileContent, Config{colors256: false})
+	expectOut = getColorHeader("filename.go - 100.0%", true, Config{}) +
 		"1 line\n" +
 		"123 " + ansi.ColorCode("green") + "green" + ansi.ColorCode("reset") + " 456\n" +
 		"3 line " + ansi.ColorCode("red") + "red" + ansi.ColorCode("reset") + " and other\n"
 	if string(coloredBytes) != expectOut {
-		t.Errorf("2. getCoverForFile() failed")
+		t.Errorf("2. renderFilesCover() failed")
 	}
 
 	// 256 colors
-	coloredBytes = getCoverForFile(fileProfile, fileContent, Config{colors256: true})
-	expectOut = getColorHeader("filename.go - 100.0%", true) +
+	coloredBytes = renderFileCover(fileProfile, fileContent, Config{colors256: true})
+	expectOut = getColorHeader("filename.go - 100.0%", true, Config{}) +
 		"1 line\n" +
 		"123 " + ansi.ColorCode("48") + "green" + ansi.ColorCode("reset") + " 456\n" +
 		"3 line " + ansi.ColorCode("red") + "red" + ansi.ColorCode("reset") + " and other\n"
 	if string(coloredBytes) != expectOut {
-		t.Errorf("3. getCoverForFile() failed")
+		t.Errorf("3. renderFilesCover() failed")
 	}
 
-	coloredBytes = getCoverForFile(fileProfile, fileContent, Config{summary: true})
+	coloredBytes = renderFileCover(fileProfile, fileContent, Config{summary: true})
 	expectOut = "filename.go - 100.0%\n"
 	if string(coloredBytes) != expectOut {
-		t.Errorf("4. getCoverForFile() failed; got:\n%s\nwant:\n%s", coloredBytes, expectOut)
+		t.Errorf("4. renderFilesCover() failed; got:\n%s\nwant:\n%s", coloredBytes, expectOut)
 	}
 }
 
 func Test_runGoTest(t *testing.T) {
-	err := runGoTest("./not exists dir", "", []string{}, true)
+	_, err := runGoTest("./not exists dir", "", coverModeCount, []string{}, true)
 	if err == nil {
 		t.Errorf("runGoTest() error failed")
 	}
-}
-
-func Test_guessAbsPathInGOPATH(t *testing.T) {
-	GOPATH := ""
-	absPath, err := guessAbsPathInGOPATH(GOPATH, "file.golang")
-	if absPath != "" || err == nil {
-		t.Errorf("1. guessAbsPathInGOPATH() empty GOPATH")
-	}
-
-	cwd, _ := os.Getwd()
-
-	GOPATH = filepath.Join(cwd, "testdata")
-	absPath, err = guessAbsPathInGOPATH(GOPATH, "file.golang")
-	if err != nil {
-		t.Errorf("2. guessAbsPathInGOPATH() error: %s", err)
-	}
-	if absPath != filepath.Join(cwd, "testdata", "src", "file.golang") {
-		t.Errorf("3. guessAbsPathInGOPATH() empty GOPATH")
-	}
 
-	GOPATH = filepath.Join(cwd, "testdata") + string(os.PathListSeparator) + "/tmp"
-	absPath, err = guessAbsPathInGOPATH(GOPATH, "file.golang")
-	if err != nil {
-		t.Errorf("4. guessAbsPathInGOPATH() error: %s", err)
+	// profile from previous run is cleaned
+	coverFileName := filepath.Join(t.TempDir(), "cover.out")
+	if err := os.WriteFile(coverFileName, []byte("mode: count\nstale.go:1.1,2.2 1 1\n"), 0o600); err != nil {
+		t.Fatal(err)
 	}
-	if absPath != filepath.Join(cwd, "testdata", "src", "file.golang") {
-		t.Errorf("5. guessAbsPathInGOPATH() empty GOPATH")
+	result, err := runGoTest("./not exists dir", coverFileName, coverModeCount, []string{}, true)
+	if err == nil || len(result.output) == 0 {
+		t.Errorf("runGoTest() error failed")
 	}
-
-	GOPATH = "/tmp" + string(os.PathListSeparator) + "/"
-	absPath, err = guessAbsPathInGOPATH(GOPATH, "file.golang")
-	if absPath != "" || err == nil {
-		t.Errorf("6. guessAbsPathInGOPATH() file not in GOPATH")
+	if content, err := os.ReadFile(coverFileName); err != nil || strings.Contains(string(content), "stale.go") {
+		t.Errorf("runGoTest() cover profile is not cleaned: %q, %v", content, err)
 	}
 }
 
-func Test_getStatForProfileBlocks(t *testing.T) {
-	profileBlocks := []cover.ProfileBlock{
-		{
-			StartLine: 2,
-			StartCol:  5,
-			EndLine:   2,
-			EndCol:    10,
-			NumStmt:   1,
-			Count:     1,
-		},
-	}
-
-	stat := getStatForProfileBlocks(profileBlocks)
-	if stat != 100.0 {
-		t.Errorf("1. getStatForProfileBlocks() failed")
-	}
-
-	profileBlocks = append(profileBlocks,
-		cover.ProfileBlock{
-			StartLine: 3,
-			StartCol:  5,
-			EndLine:   3,
-			EndCol:    10,
-			NumStmt:   1,
-			Count:     0,
-		},
-	)
-	stat = getStatForProfileBlocks(profileBlocks)
-	if stat != 50.0 {
-		t.Errorf("2. getStatForProfileBlocks() failed")
-	}
-
-	profileBlocks = append(profileBlocks,
-		cover.ProfileBlock{
-			StartLine: 4,
-			StartCol:  5,
-			EndLine:   4,
-			EndCol:    10,
-			NumStmt:   1,
-			Count:     0,
-		},
-		cover.ProfileBlock{
-			StartLine: 4,
-			StartCol:  5,
-			EndLine:   4,
-			EndCol:    10,
-			NumStmt:   1,
-			Count:     0,
-		},
-	)
-	stat = getStatForProfileBlocks(profileBlocks)
-	if stat != 25.0 {
-		t.Errorf("3. getStatForProfileBlocks() failed")
+func Test_getCoverMode(t *testing.T) {
+	tests := []struct {
+		coverMode string
+		args      []string
+		want      string
+		wantErr   bool
+	}{
+		{coverMode: "", args: nil, want: "count"},
+		{coverMode: "", args: []string{"-short", "-race"}, want: "atomic"},
+		{coverMode: "set", args: []string{"-race"}, want: "set"},
+		{coverMode: "atomic", args: nil, want: "atomic"},
+		{coverMode: "sets", args: nil, wantErr: true},
+		{coverMode: "", args: []string{"-race=true"}, want: "atomic"},
+		{coverMode: "", args: []string{"--race"}, want: "atomic"},
+		{coverMode: "", args: []string{"-race", "-race=false"}, want: "count"},
+		{coverMode: "", args: []string{"-covermode=set"}, wantErr: true},
+		{coverMode: "count", args: []string{"--covermode", "set"}, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		got, err := getCoverMode(tt.coverMode, tt.args)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%d. getCoverMode(%q, %v) = %q, %v, want %q", i, tt.coverMode, tt.args, got, err, tt.want)
+		}
 	}
 }
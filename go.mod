@@ -4,13 +4,11 @@ go 1.19
 
 require (
 	github.com/mattn/go-colorable v0.1.12
+	github.com/mattn/go-isatty v0.0.14
 	github.com/mattn/go-shellwords v1.0.12
 	github.com/mgutz/ansi v0.0.0-20200706080929-d51e80ef957d
 	github.com/msoap/byline v1.1.1
 	golang.org/x/tools v0.1.12
 )
 
-require (
-	github.com/mattn/go-isatty v0.0.14 // indirect
-	golang.org/x/sys v0.0.0-20220722155257-8c9f86f7a55f // indirect
-)
+require golang.org/x/sys v0.0.0-20220722155257-8c9f86f7a55f // indirect
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+// testEvent - fields of event from `go test -json` output which are used, see `go doc test2json`
+type testEvent struct {
+	Action string
+	Test   string
+	Output string
+}
+
+// testResult - result of one test
+type testResult struct {
+	name   string
+	action string // pass, fail or skip
+}
+
+// goTestResult - result of go test for one package
+type goTestResult struct {
+	// output of failed tests and of package, without output of passed tests
+	output []byte
+	tests  []testResult
+}
+
+// failedTests - names of failed tests
+func (result goTestResult) failedTests() (names []string) {
+	for _, test := range result.tests {
+		if test.action == "fail" {
+			names = append(names, test.name)
+		}
+	}
+	return names
+}
+
+// testEventsCollector - collects results of tests and output from stream of events
+type testEventsCollector struct {
+	result     goTestResult
+	testOutput map[string][]byte
+}
+
+func (collector *testEventsCollector) add(event testEvent) {
+	if collector.testOutput == nil {
+		collector.testOutput = map[string][]byte{}
+	}
+
+	if event.Test == "" {
+		collector.result.output = append(collector.result.output, event.Output...)
+		return
+	}
+
+	collector.testOutput[event.Test] = append(collector.testOutput[event.Test], event.Output...)
+
+	switch event.Action {
+	case "pass", "fail", "skip":
+		collector.result.tests = append(collector.result.tests, testResult{name: event.Test, action: event.Action})
+		if event.Action == "fail" {
+			collector.result.output = append(collector.result.output, collector.testOutput[event.Test]...)
+		}
+		delete(collector.testOutput, event.Test)
+	}
+}
+
+// readTestEvents - read `go test -json` output, lines which are not JSON are treated as output of package
+func readTestEvents(reader io.Reader, handler func(testEvent)) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		event := testEvent{}
+		if !bytes.HasPrefix(line, []byte("{")) || json.Unmarshal(line, &event) != nil {
+			event = testEvent{Action: "output", Output: string(line) + "\n"}
+		}
+		handler(event)
+	}
+
+	return scanner.Err()
+}
+
+// testProgress - live progress line of running tests, redrawn periodically,
+// stderr of go test is written through it for not mix with progress line
+type testProgress struct {
+	mu       sync.Mutex
+	writer   io.Writer
+	path     string
+	started  time.Time
+	passed   int
+	failed   int
+	skipped  int
+	running  string
+	done     chan struct{}
+	finished sync.WaitGroup
+}
+
+// newTestProgress - show progress in writer and update it periodically until stop() is called
+func newTestProgress(writer io.Writer, path string) *testProgress {
+	progress := &testProgress{
+		writer:  writer,
+		path:    path,
+		started: time.Now(),
+		done:    make(chan struct{}),
+	}
+
+	progress.finished.Add(1)
+	go func() {
+		defer progress.finished.Done()
+		ticker := time.NewTicker(250 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				progress.draw()
+			case <-progress.done:
+				return
+			}
+		}
+	}()
+
+	return progress
+}
+
+func (progress *testProgress) update(event testEvent) {
+	progress.mu.Lock()
+	switch {
+	case event.Test == "":
+	case event.Action == "run":
+		progress.running = event.Test
+	case event.Action == "pass":
+		progress.passed++
+	case event.Action == "fail":
+		progress.failed++
+	case event.Action == "skip":
+		progress.skipped++
+	}
+	progress.mu.Unlock()
+}
+
+func (progress *testProgress) getLine() string {
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+
+	line := fmt.Sprintf("%s: %d passed, %d failed, %d skipped, %.1fs",
+		progress.path, progress.passed, progress.failed, progress.skipped, time.Since(progress.started).Seconds())
+	if progress.running != "" {
+		running := progress.running
+		if len(running) > 40 {
+			running = running[:37] + "..."
+		}
+		line += ", " + running
+	}
+
+	return line
+}
+
+func (progress *testProgress) draw() {
+	line := progress.getLine()
+
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+	// "\r\033[K" - to begin of line and clear it
+	_, _ = fmt.Fprint(progress.writer, "\r\033[K"+line)
+}
+
+// Write - clear progress line and write output, progress line is redrawn by ticker
+func (progress *testProgress) Write(data []byte) (int, error) {
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+
+	if _, err := fmt.Fprint(progress.writer, "\r\033[K"); err != nil {
+		return 0, err
+	}
+	return progress.writer.Write(data)
+}
+
+// stop - stop updating and clear progress line
+func (progress *testProgress) stop() {
+	close(progress.done)
+	progress.finished.Wait()
+
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+	_, _ = fmt.Fprint(progress.writer, "\r\033[K")
+}
+
+// runGoTest - run tests of one package with `go test -json`, returns output of failed tests and results of each test
+func runGoTest(path string, coverFileName string, coverMode string, goTestArgs []string, hideStderr bool) (result goTestResult, err error) {
+	// clean profile from previous package, if tests of this package failed to build it will not be written
+	if coverFileName != "" {
+		if err := os.Truncate(coverFileName, 0); err != nil {
+			return result, err
+		}
+	}
+
+	args := []string{"test", goTestJSON, goTestCoverProfile + "=" + coverFileName, goTestCoverMode + "=" + coverMode}
+	args = append(args, goTestArgs...)
+	args = append(args, path)
+	osExec := exec.Command("go", args...) // #nosec
+
+	collector := &testEventsCollector{}
+	handler := collector.add
+	switch {
+	case !hideStderr && isatty.IsTerminal(os.Stderr.Fd()):
+		progress := newTestProgress(os.Stderr, path)
+		osExec.Stderr = progress
+		handler = func(event testEvent) {
+			collector.add(event)
+			progress.update(event)
+		}
+		defer progress.stop()
+	case !hideStderr:
+		osExec.Stderr = os.Stderr
+	}
+
+	stdout, err := osExec.StdoutPipe()
+	if err != nil {
+		return result, err
+	}
+	if err := osExec.Start(); err != nil {
+		return result, err
+	}
+
+	errRead := readTestEvents(stdout, handler)
+	if err := osExec.Wait(); err != nil {
+		return collector.result, err
+	}
+
+	return collector.result, errRead
+}
+
+// testFailure - package with failed tests
+type testFailure struct {
+	path   string
+	err    error
+	result goTestResult
+}
+
+// getFailuresReport - section with output of failed tests
+func getFailuresReport(failures []testFailure, config Config) string {
+	result := "\n" + getColorHeader(fmt.Sprintf("%d packages failed:", len(failures)), true, config)
+	for _, failure := range failures {
+		header := fmt.Sprintf("%s: %s", failure.path, failure.err)
+		if failedTests := failure.result.failedTests(); len(failedTests) > 0 {
+			header += ", failed tests: " + strings.Join(failedTests, ", ")
+		}
+		result += getColorHeader(header, false, config)
+		result += string(failure.result.output)
+	}
+
+	return result
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testEventsStream = `{"Action":"start","Package":"example.com/a"}
+{"Action":"run","Package":"example.com/a","Test":"TestOk"}
+{"Action":"output","Package":"example.com/a","Test":"TestOk","Output":"=== RUN   TestOk\n"}
+{"Action":"output","Package":"example.com/a","Test":"TestOk","Output":"--- PASS: TestOk (0.00s)\n"}
+{"Action":"pass","Package":"example.com/a","Test":"TestOk","Elapsed":0.5}
+{"Action":"run","Package":"example.com/a","Test":"TestFail"}
+{"Action":"output","Package":"example.com/a","Test":"TestFail","Output":"=== RUN   TestFail\n"}
+{"Action":"output","Package":"example.com/a","Test":"TestFail","Output":"    a_test.go:7: fail\n"}
+{"Action":"output","Package":"example.com/a","Test":"TestFail","Output":"--- FAIL: TestFail (0.00s)\n"}
+{"Action":"fail","Package":"example.com/a","Test":"TestFail","Elapsed":0}
+{"Action":"skip","Package":"example.com/a","Test":"TestSkip","Elapsed":0}
+not a json line
+{"Action":"output","Package":"example.com/a","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/a","Elapsed":0.01}
+`
+
+func Test_readTestEvents(t *testing.T) {
+	collector := &testEventsCollector{}
+	if err := readTestEvents(strings.NewReader(testEventsStream), collector.add); err != nil {
+		t.Fatalf("readTestEvents() failed: %s", err)
+	}
+
+	wantTests := []testResult{
+		{name: "TestOk", action: "pass"},
+		{name: "TestFail", action: "fail"},
+		{name: "TestSkip", action: "skip"},
+	}
+	if !reflect.DeepEqual(collector.result.tests, wantTests) {
+		t.Errorf("readTestEvents() tests:\ngot : %v\nwant: %v", collector.result.tests, wantTests)
+	}
+
+	wantOutput := "=== RUN   TestFail\n    a_test.go:7: fail\n--- FAIL: TestFail (0.00s)\nnot a json line\nFAIL\n"
+	if string(collector.result.output) != wantOutput {
+		t.Errorf("readTestEvents() output:\ngot :\n%s\nwant:\n%s", collector.result.output, wantOutput)
+	}
+
+	if failed := collector.result.failedTests(); !reflect.DeepEqual(failed, []string{"TestFail"}) {
+		t.Errorf("failedTests() = %v", failed)
+	}
+}
+
+func Test_testProgress(t *testing.T) {
+	writer := &bytes.Buffer{}
+	progress := &testProgress{writer: writer, path: "./pkg", started: time.Now(), done: make(chan struct{})}
+
+	progress.update(testEvent{Action: "run", Test: "TestOk"})
+	progress.update(testEvent{Action: "pass", Test: "TestOk"})
+	progress.update(testEvent{Action: "fail", Test: "TestFail"})
+	progress.update(testEvent{Action: "skip", Test: "TestSkip"})
+	progress.update(testEvent{Action: "run", Test: "TestVeryLongNameOfTestWhichIsLongerThanFortyChars"})
+
+	line := progress.getLine()
+	if !strings.HasPrefix(line, "./pkg: 1 passed, 1 failed, 1 skipped, 0.0s, TestVeryLongNameOfTestWhichIsLongerTh...") {
+		t.Errorf("getLine() = %q", line)
+	}
+	if writer.Len() != 0 {
+		t.Errorf("update() draws progress: %q", writer.String())
+	}
+
+	progress.draw()
+	if !strings.HasPrefix(writer.String(), "\r\033[K./pkg: 1 passed") {
+		t.Errorf("draw() = %q", writer.String())
+	}
+
+	writer.Reset()
+	if _, err := progress.Write([]byte("stderr line\n")); err != nil || writer.String() != "\r\033[Kstderr line\n" {
+		t.Errorf("Write() = %q, %v", writer.String(), err)
+	}
+
+	progress.stop()
+	if !strings.HasSuffix(writer.String(), "\r\033[K") {
+		t.Errorf("stop() does not clear line: %q", writer.String())
+	}
+}
+
+func Test_getFailuresReport(t *testing.T) {
+	failures := []testFailure{
+		{
+			path:   "./a",
+			err:    errors.New("exit status 1"),
+			result: goTestResult{output: []byte("--- FAIL: TestA\nFAIL\n"), tests: []testResult{{name: "TestA", action: "fail"}, {name: "TestB", action: "pass"}}},
+		},
+		{path: "./b", err: errors.New("exit status 2")},
+	}
+
+	got := getFailuresReport(failures, Config{plain: true})
+	want := "\n2 packages failed:\n~~~~~~~~~~~~~~~~~~\n" +
+		"./a: exit status 1, failed tests: TestA\n--- FAIL: TestA\nFAIL\n" +
+		"./b: exit status 2\n"
+	if got != want {
+		t.Errorf("getFailuresReport():\ngot :\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+// heatShades - 256-colors shades for heatmap, from cold (rarely executed) to hot (often executed) code
+var heatShades = []string{"27", "33", "39", "45", "49", "118", "190", "220", "208", "196"}
+
+// heatBlock - profile block with the file where it is
+type heatBlock struct {
+	cover.ProfileBlock
+	fileName string
+	line     string // first line of source of block
+}
+
+// getMaxCount - maximum execution count of blocks in files
+func getMaxCount(files []carpet.FileCover) (result int) {
+	for _, file := range files {
+		for _, block := range file.Profile.Blocks {
+			if block.Count > result {
+				result = block.Count
+			}
+		}
+	}
+
+	return result
+}
+
+// getHeatNorm - execution count on logarithmic scale in [0, 1]
+func getHeatNorm(count, maxCount int) float64 {
+	if count <= 0 || maxCount <= 1 {
+		return 0
+	}
+	if count >= maxCount {
+		return 1
+	}
+
+	return math.Log(float64(count)) / math.Log(float64(maxCount))
+}
+
+// getHeatmapColor - get color of covered code for heatmap: blue - cold, red - hot
+func getHeatmapColor(normCover float64, config Config) string {
+	if config.colors256 {
+		return colorCode(getShade(heatShades, normCover))
+	}
+
+	switch {
+	case normCover < 0.4:
+		return colorCode("blue+h")
+	case normCover < 0.8:
+		return colorCode("yellow+h")
+	default:
+		return colorCode("red+h")
+	}
+}
+
+// getHottestBlocks - get top-N blocks with the largest execution count
+func getHottestBlocks(files []carpet.FileCover, limit int) (result []heatBlock) {
+	for _, file := range files {
+		lines := strings.Split(string(file.Content), "\n")
+		for _, block := range file.Profile.Blocks {
+			if block.Count == 0 {
+				continue
+			}
+
+			line := ""
+			if block.StartLine > 0 && block.StartLine <= len(lines) {
+				line = strings.TrimSpace(lines[block.StartLine-1])
+			}
+			result = append(result, heatBlock{
+				ProfileBlock: block,
+				fileName:     strings.TrimLeft(file.Profile.FileName, "_"),
+				line:         line,
+			})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Count > result[j].Count })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
+
+// getHeatmapReport - list of the hottest blocks with file:line
+func getHeatmapReport(files []carpet.FileCover, limit int, config Config) string {
+	blocks := getHottestBlocks(files, limit)
+	if len(blocks) == 0 {
+		return ""
+	}
+
+	maxCount := blocks[0].Count
+	countWidth := len(fmt.Sprint(maxCount))
+	result := "\n" + getColorHeader(fmt.Sprintf("Hottest blocks (top %d):", len(blocks)), true, config)
+	for _, block := range blocks {
+		position := fmt.Sprintf("%s:%d", block.fileName, block.StartLine)
+		if block.EndLine != block.StartLine {
+			position += fmt.Sprintf("-%d", block.EndLine)
+		}
+
+		line := block.line
+		if len(line) > 60 {
+			line = line[:57] + "..."
+		}
+
+		count := fmt.Sprintf("%*d", countWidth, block.Count)
+		if !config.plain {
+			count = getHeatmapColor(getHeatNorm(block.Count, maxCount), config) + count + colorCode("reset")
+		}
+		result += fmt.Sprintf("%s  %s  %s\n", count, position, line)
+	}
+
+	return result
+}
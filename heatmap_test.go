@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+func Test_getHeatNorm(t *testing.T) {
+	tests := []struct {
+		count, maxCount int
+		want            float64
+	}{
+		{count: 0, maxCount: 100, want: 0},
+		{count: 1, maxCount: 100, want: 0},
+		{count: 10, maxCount: 100, want: 0.5},
+		{count: 100, maxCount: 100, want: 1},
+		{count: 1, maxCount: 1, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := getHeatNorm(tt.count, tt.maxCount); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("getHeatNorm(%d, %d) = %v, want %v", tt.count, tt.maxCount, got, tt.want)
+		}
+	}
+}
+
+func Test_getHeatmapReport(t *testing.T) {
+	files := []carpet.FileCover{
+		{
+			Profile: &cover.Profile{FileName: "pkg/a.go", Blocks: []cover.ProfileBlock{
+				{StartLine: 2, EndLine: 3, NumStmt: 1, Count: 5},
+				{StartLine: 4, EndLine: 4, NumStmt: 1, Count: 0},
+			}},
+			Content: []byte("package pkg\n\tfor i := range x {\n\t}\n\treturn\n"),
+		},
+		{
+			Profile: &cover.Profile{FileName: "pkg/b.go", Blocks: []cover.ProfileBlock{
+				{StartLine: 2, EndLine: 2, NumStmt: 1, Count: 1200},
+				{StartLine: 3, EndLine: 3, NumStmt: 1, Count: 1},
+			}},
+			Content: []byte("package pkg\n\tsum += i\n\treturn sum\n"),
+		},
+	}
+
+	if got := getMaxCount(files); got != 1200 {
+		t.Errorf("getMaxCount() = %d, want 1200", got)
+	}
+
+	got := getHeatmapReport(files, 2, Config{plain: true})
+	want := "\n" + getColorHeader("Hottest blocks (top 2):", true, Config{plain: true}) +
+		"1200  pkg/b.go:2  sum += i\n" +
+		"   5  pkg/a.go:2-3  for i := range x {\n"
+	if got != want {
+		t.Errorf("getHeatmapReport():\ngot : %q\nwant: %q", got, want)
+	}
+
+	if got := getHeatmapReport(nil, 10, Config{}); got != "" {
+		t.Errorf("getHeatmapReport() for empty files = %q", got)
+	}
+}
+
+func Test_renderFilesCover_heatmapMax(t *testing.T) {
+	files := []carpet.FileCover{{
+		Profile: &cover.Profile{FileName: "pkg/a.go", Mode: "count", Blocks: []cover.ProfileBlock{
+			{StartLine: 2, StartCol: 2, EndLine: 2, EndCol: 10, NumStmt: 1, Count: 10},
+		}},
+		Content: []byte("package pkg\n\tsum += i\n"),
+	}}
+
+	config := Config{heatmap: 1}
+	ownScale, _ := renderFilesCover(files, config)
+	config.heatmapMax = 100
+	profileScale, _ := renderFilesCover(files, config)
+	if bytes.Equal(ownScale, profileScale) {
+		t.Errorf("renderFilesCover() ignores heatmapMax of profile: %q", profileScale)
+	}
+	if !bytes.Contains(profileScale, []byte(getHeatmapColor(getHeatNorm(10, 100), config))) {
+		t.Errorf("renderFilesCover() is not normalized by heatmapMax: %q", profileScale)
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/msoap/go-carpet/carpet"
+)
+
+const defaultHistoryFileName = "history.jsonl"
+
+// sparkChars - bars of sparkline from the lowest to the highest value
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+// historyRecord - coverage of one run in history file (one JSON per line)
+type historyRecord struct {
+	Commit   string             `json:"commit,omitempty"`
+	Time     time.Time          `json:"time"`
+	Coverage float64            `json:"coverage"`
+	Packages map[string]float64 `json:"packages"`
+}
+
+// newHistoryRecord - record with total and per-package coverage of files for current commit
+func newHistoryRecord(files []carpet.FileCover) historyRecord {
+	type stat struct{ statements, covered int }
+	packages := map[string]stat{}
+	for _, fileStat := range carpet.GetFileStats(files) {
+		pkg := packages[fileStat.Package]
+		pkg.statements += fileStat.Statements
+		pkg.covered += fileStat.Covered
+		packages[fileStat.Package] = pkg
+	}
+
+	result := historyRecord{
+		Commit:   getGitCommit(),
+		Time:     time.Now().UTC().Truncate(time.Second),
+		Coverage: carpet.GetSummary(files).Coverage,
+		Packages: map[string]float64{},
+	}
+	for name, pkg := range packages {
+		if pkg.statements > 0 {
+			result.Packages[name] = float64(pkg.covered) / float64(pkg.statements) * 100
+		}
+	}
+
+	return result
+}
+
+// getGitCommit - hash of current git commit, empty if it is not a git repository
+func getGitCommit() string {
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// appendHistory - append record to history file
+func appendHistory(fileName string, record historyRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) // #nosec
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// loadHistory - read records from history file
+func loadHistory(r io.Reader) ([]historyRecord, error) {
+	result := []historyRecord{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		record := historyRecord{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, fmt.Errorf("failed to parse history line %d: %w", lineNum, err)
+		}
+		result = append(result, record)
+	}
+
+	return result, scanner.Err()
+}
+
+// getSparkline - one char per value, scaled between minimum and maximum values, NaN values are shown as space
+func getSparkline(values []float64) string {
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			minValue, maxValue = math.Min(minValue, value), math.Max(maxValue, value)
+		}
+	}
+
+	result := make([]rune, 0, len(values))
+	for _, value := range values {
+		switch {
+		case math.IsNaN(value):
+			result = append(result, ' ')
+		case maxValue-minValue < 0.05:
+			result = append(result, sparkChars[len(sparkChars)/2])
+		default:
+			index := int((value - minValue) / (maxValue - minValue) * float64(len(sparkChars)-1))
+			result = append(result, sparkChars[index])
+		}
+	}
+
+	return string(result)
+}
+
+// getHistoryReport - sparklines of total and per-package coverage with the last value and change over the period
+func getHistoryReport(records []historyRecord, filter []string) string {
+	if len(records) == 0 {
+		return "history is empty\n"
+	}
+
+	packageNames := []string{}
+	seen := map[string]bool{}
+	for _, record := range records {
+		for name := range record.Packages {
+			if !seen[name] && (len(filter) == 0 || isSliceInString(name, filter)) {
+				seen[name] = true
+				packageNames = append(packageNames, name)
+			}
+		}
+	}
+	sort.Strings(packageNames)
+
+	type series struct {
+		name   string
+		values []float64
+	}
+	allSeries := []series{{name: "total", values: make([]float64, 0, len(records))}}
+	for _, record := range records {
+		allSeries[0].values = append(allSeries[0].values, record.Coverage)
+	}
+	for _, name := range packageNames {
+		values := make([]float64, 0, len(records))
+		for _, record := range records {
+			value, ok := record.Packages[name]
+			if !ok {
+				value = math.NaN()
+			}
+			values = append(values, value)
+		}
+		allSeries = append(allSeries, series{name: name, values: values})
+	}
+
+	nameWidth := 0
+	for _, item := range allSeries {
+		if len(item.name) > nameWidth {
+			nameWidth = len(item.name)
+		}
+	}
+
+	first, last := records[0], records[len(records)-1]
+	result := &strings.Builder{}
+	fmt.Fprintf(result, "%d records from %s to %s\n", len(records), formatHistoryRecord(first), formatHistoryRecord(last))
+	for _, item := range allSeries {
+		firstValue, lastValue := math.NaN(), math.NaN()
+		for _, value := range item.values {
+			if !math.IsNaN(value) {
+				if math.IsNaN(firstValue) {
+					firstValue = value
+				}
+				lastValue = value
+			}
+		}
+
+		fmt.Fprintf(result, "%-*s  %s  %5.1f%% (%+.1f%%)\n", nameWidth, item.name, getSparkline(item.values), lastValue, lastValue-firstValue)
+	}
+
+	return result.String()
+}
+
+func formatHistoryRecord(record historyRecord) string {
+	result := record.Time.Format("2006-01-02 15:04")
+	if len(record.Commit) >= 7 {
+		result += " (" + record.Commit[:7] + ")"
+	}
+
+	return result
+}
+
+// runHistory - "go-carpet history" subcommand: show coverage over time from history file
+func runHistory(args []string) error {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	fileName := flags.String("file", defaultHistoryFileName, "history `file` recorded with -record option")
+	limit := flags.Int("n", 50, "show last `N` records")
+	flags.Usage = func() {
+		fmt.Println("usage: go-carpet history [options] [packages]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	file, err := os.Open(*fileName)
+	if err != nil {
+		return err
+	}
+	records, err := loadHistory(file)
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return err
+	}
+
+	if *limit > 0 && len(records) > *limit {
+		records = records[len(records)-*limit:]
+	}
+
+	_, err = io.WriteString(os.Stdout, getHistoryReport(records, flags.Args()))
+	return err
+}
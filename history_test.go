@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+func Test_getSparkline(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   string
+	}{
+		{values: []float64{10, 20, 30, 40, 50, 60, 70, 80}, want: "▁▂▃▄▅▆▇█"},
+		{values: []float64{50, 50, 50}, want: "▅▅▅"},
+		{values: []float64{0, math.NaN(), 100}, want: "▁ █"},
+		{values: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := getSparkline(tt.values); got != tt.want {
+			t.Errorf("getSparkline(%v) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
+
+func Test_history(t *testing.T) {
+	files := []carpet.FileCover{
+		{Profile: &cover.Profile{FileName: "example.com/pkg/a.go", Blocks: []cover.ProfileBlock{{NumStmt: 3, Count: 1}, {NumStmt: 1, Count: 0}}}},
+		{Profile: &cover.Profile{FileName: "example.com/pkg/b.go", Blocks: []cover.ProfileBlock{{NumStmt: 4, Count: 0}}}},
+		{Profile: &cover.Profile{FileName: "example.com/cmd/main.go", Blocks: []cover.ProfileBlock{{NumStmt: 2, Count: 1}}}},
+	}
+
+	record := newHistoryRecord(files)
+	wantPackages := map[string]float64{"example.com/pkg": 37.5, "example.com/cmd": 100}
+	if record.Coverage != 50 || !reflect.DeepEqual(record.Packages, wantPackages) || record.Time.IsZero() {
+		t.Errorf("newHistoryRecord() = %+v", record)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "history.jsonl")
+	records := []historyRecord{
+		{Commit: "1234567890abcdef", Time: time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC), Coverage: 40, Packages: map[string]float64{"example.com/pkg": 20}},
+		{Commit: "abcdef1234567890", Time: time.Date(2026, 1, 3, 10, 0, 0, 0, time.UTC), Coverage: 50, Packages: map[string]float64{"example.com/pkg": 37.5, "example.com/cmd": 100}},
+	}
+	for _, item := range records {
+		if err := appendHistory(fileName, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadHistory(strings.NewReader(string(content)))
+	if err != nil || !reflect.DeepEqual(loaded, records) {
+		t.Errorf("loadHistory() = %+v, %v", loaded, err)
+	}
+
+	want := "2 records from 2026-01-02 10:00 (1234567) to 2026-01-03 10:00 (abcdef1)\n" +
+		"total            ▁█   50.0% (+10.0%)\n" +
+		"example.com/cmd   ▅  100.0% (+0.0%)\n" +
+		"example.com/pkg  ▁█   37.5% (+17.5%)\n"
+	if got := getHistoryReport(records, nil); got != want {
+		t.Errorf("getHistoryReport():\ngot :\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := getHistoryReport(records, []string{"cmd"}); strings.Contains(got, "example.com/pkg") || !strings.Contains(got, "example.com/cmd") {
+		t.Errorf("getHistoryReport() with filter:\n%s", got)
+	}
+
+	if _, err := loadHistory(strings.NewReader("{}\nnot json\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("loadHistory() with invalid line: %v", err)
+	}
+}
@@ -0,0 +1,515 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/msoap/go-carpet/carpet"
+)
+
+const (
+	lspSource = "go-carpet"
+
+	// LSP constants
+	lspSeverityHint       = 4
+	lspErrMethodNotFound  = -32601
+	lspErrInvalidRequest  = -32600
+	lspErrParse           = -32700
+	lspTokenCovered       = 0 // index in lspTokenTypes
+	lspTokenUncovered     = 1
+	lspDefaultProfileName = "cover.out"
+)
+
+// lspTokenTypes - legend of semantic tokens
+var lspTokenTypes = []string{"covered", "uncovered"}
+
+// lspMessage - JSON-RPC request, notification or response
+type lspMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+// lspResponse - JSON-RPC response, result is required on success and must not exist on error
+type lspResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *lspError        `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"` // in UTF-16 code units
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+// lspServer - language server, which publishes coverage from cover profile
+type lspServer struct {
+	profileName string
+	in          *bufio.Reader
+	out         io.Writer
+
+	watchInterval time.Duration // interval of checking cover profile for changes, 0 - without watching
+
+	writeMu sync.Mutex
+	nextID  int
+
+	reloadMu       sync.Mutex
+	mu             sync.Mutex
+	files          map[string]carpet.FileCover // by URI
+	profileModTime time.Time
+	refreshSupport bool
+	isShutdown     bool
+}
+
+// runLSP - "go-carpet lsp" subcommand: language server over stdio
+func runLSP(args []string) error {
+	flags := flag.NewFlagSet("lsp", flag.ExitOnError)
+	profileName := flags.String("profile", lspDefaultProfileName, "cover profile `file`, relative to the workspace root")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	server := newLSPServer(*profileName, os.Stdin, os.Stdout)
+	server.watchInterval = watchInterval
+
+	return server.serve()
+}
+
+func newLSPServer(profileName string, in io.Reader, out io.Writer) *lspServer {
+	return &lspServer{
+		profileName: profileName,
+		in:          bufio.NewReader(in),
+		out:         out,
+		files:       map[string]carpet.FileCover{},
+	}
+}
+
+// serve - handle messages until "exit" notification or end of input
+func (s *lspServer) serve() error {
+	for {
+		body, err := readLSPMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		msg := lspMessage{}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			// not valid JSON is reported and skipped
+			if err := s.reply(nil, nil, &lspError{Code: lspErrParse, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if msg.Method == "exit" {
+			s.mu.Lock()
+			isShutdown := s.isShutdown
+			s.mu.Unlock()
+			if !isShutdown {
+				return fmt.Errorf("exit without shutdown")
+			}
+			return nil
+		}
+
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *lspServer) handle(msg lspMessage) error {
+	switch msg.Method {
+	case "initialize":
+		return s.initialize(msg)
+	case "initialized":
+		s.reload()
+		if s.watchInterval > 0 {
+			go s.watch(s.watchInterval)
+		}
+		return nil
+	case "textDocument/semanticTokens/full":
+		params := struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+		}{}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return s.reply(msg.ID, nil, &lspError{Code: lspErrInvalidRequest, Message: err.Error()})
+		}
+
+		s.mu.Lock()
+		file, ok := s.files[params.TextDocument.URI]
+		s.mu.Unlock()
+		if !ok {
+			return s.reply(msg.ID, map[string]interface{}{"data": []uint32{}}, nil)
+		}
+		return s.reply(msg.ID, map[string]interface{}{"data": getLSPSemanticTokens(file)}, nil)
+	case "shutdown":
+		s.mu.Lock()
+		s.isShutdown = true
+		s.mu.Unlock()
+		return s.reply(msg.ID, nil, nil)
+	}
+
+	if msg.ID == nil || msg.Method == "" {
+		// notifications and responses to our requests are ignored
+		return nil
+	}
+	return s.reply(msg.ID, nil, &lspError{Code: lspErrMethodNotFound, Message: "method not found: " + msg.Method})
+}
+
+func (s *lspServer) initialize(msg lspMessage) error {
+	params := struct {
+		RootURI      string `json:"rootUri"`
+		Capabilities struct {
+			Workspace struct {
+				SemanticTokens struct {
+					RefreshSupport bool `json:"refreshSupport"`
+				} `json:"semanticTokens"`
+			} `json:"workspace"`
+		} `json:"capabilities"`
+	}{}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return s.reply(msg.ID, nil, &lspError{Code: lspErrInvalidRequest, Message: err.Error()})
+	}
+
+	s.mu.Lock()
+	s.refreshSupport = params.Capabilities.Workspace.SemanticTokens.RefreshSupport
+	if root := uriToPath(params.RootURI); root != "" && !filepath.IsAbs(s.profileName) {
+		s.profileName = filepath.Join(root, s.profileName)
+	}
+	s.mu.Unlock()
+
+	return s.reply(msg.ID, map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"semanticTokensProvider": map[string]interface{}{
+				"legend": map[string]interface{}{"tokenTypes": lspTokenTypes, "tokenModifiers": []string{}},
+				"full":   true,
+			},
+		},
+		"serverInfo": map[string]string{"name": lspSource, "version": version},
+	}, nil)
+}
+
+// watch - reload cover profile when it is changed
+func (s *lspServer) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		profileName, modTime := s.profileName, s.profileModTime
+		s.mu.Unlock()
+
+		if info, err := os.Stat(profileName); err == nil && !info.ModTime().Equal(modTime) {
+			s.reload()
+		}
+	}
+}
+
+// reload - load cover profile and publish diagnostics, files which are not in new profile are cleaned
+func (s *lspServer) reload() {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	s.mu.Lock()
+	profileName := s.profileName
+	s.mu.Unlock()
+
+	info, err := os.Stat(profileName)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.profileModTime = info.ModTime()
+	s.mu.Unlock()
+
+	// source files are resolved from the directory of profile
+	files, err := carpet.LoadFiles(profileName, carpet.Filter{Dir: filepath.Dir(profileName)})
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	newFiles := map[string]carpet.FileCover{}
+	for _, file := range files {
+		newFiles[pathToURI(file.FileName)] = file
+	}
+
+	s.mu.Lock()
+	oldFiles, refreshSupport := s.files, s.refreshSupport
+	s.files = newFiles
+	s.mu.Unlock()
+
+	for uri := range oldFiles {
+		if _, ok := newFiles[uri]; !ok {
+			s.publishDiagnostics(uri, nil)
+		}
+	}
+	for uri, file := range newFiles {
+		s.publishDiagnostics(uri, getLSPDiagnostics(file))
+	}
+	if refreshSupport {
+		s.request("workspace/semanticTokens/refresh", nil)
+	}
+}
+
+func (s *lspServer) publishDiagnostics(uri string, diagnostics []lspDiagnostic) {
+	if diagnostics == nil {
+		diagnostics = []lspDiagnostic{}
+	}
+	s.notify("textDocument/publishDiagnostics", map[string]interface{}{"uri": uri, "diagnostics": diagnostics})
+}
+
+func (s *lspServer) reply(id *json.RawMessage, result interface{}, lspErr *lspError) error {
+	response := lspResponse{JSONRPC: "2.0", ID: id, Error: lspErr}
+	if id == nil {
+		null := json.RawMessage("null")
+		response.ID = &null
+	}
+	if lspErr == nil {
+		body, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		response.Result = body
+	}
+
+	return s.write(response)
+}
+
+func (s *lspServer) notify(method string, params interface{}) {
+	body, err := json.Marshal(params)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	if err := s.write(lspMessage{JSONRPC: "2.0", Method: method, Params: body}); err != nil {
+		log.Print(err)
+	}
+}
+
+func (s *lspServer) request(method string, params interface{}) {
+	s.writeMu.Lock()
+	s.nextID++
+	id := json.RawMessage(strconv.Itoa(s.nextID))
+	s.writeMu.Unlock()
+
+	msg := lspMessage{JSONRPC: "2.0", ID: &id, Method: method}
+	if params != nil {
+		body, err := json.Marshal(params)
+		if err != nil {
+			log.Print(err)
+			return
+		}
+		msg.Params = body
+	}
+	if err := s.write(msg); err != nil {
+		log.Print(err)
+	}
+}
+
+func (s *lspServer) write(msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+// readLSPMessage - read one message with "Content-Length" header
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line == "" && length == -1 {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to read LSP header: %w", err)
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(name, "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("invalid Content-Length: %w", err)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("LSP message without Content-Length header")
+	}
+
+	body := make([]byte, length)
+	_, err := io.ReadFull(r, body)
+	return body, err
+}
+
+// getLSPDiagnostics - hints for not covered blocks
+func getLSPDiagnostics(file carpet.FileCover) []lspDiagnostic {
+	lines := strings.Split(string(file.Content), "\n")
+	result := []lspDiagnostic{}
+	for _, block := range file.Profile.Blocks {
+		if block.Count > 0 || block.NumStmt == 0 {
+			continue
+		}
+
+		result = append(result, lspDiagnostic{
+			Range: lspRange{
+				Start: lspPosition{Line: block.StartLine - 1, Character: getUTF16Column(lines, block.StartLine, block.StartCol)},
+				End:   lspPosition{Line: block.EndLine - 1, Character: getUTF16Column(lines, block.EndLine, block.EndCol)},
+			},
+			Severity: lspSeverityHint,
+			Source:   lspSource,
+			Message:  fmt.Sprintf("not covered by tests (%d statements)", block.NumStmt),
+		})
+	}
+
+	return result
+}
+
+// lspToken - semantic token in one line
+type lspToken struct {
+	line, start, length, tokenType int
+}
+
+// getLSPSemanticTokens - encoded semantic tokens of covered and not covered blocks, one token per line of block
+func getLSPSemanticTokens(file carpet.FileCover) []uint32 {
+	lines := strings.Split(string(file.Content), "\n")
+	tokens := []lspToken{}
+	for _, block := range file.Profile.Blocks {
+		if block.NumStmt == 0 {
+			continue
+		}
+
+		tokenType := lspTokenCovered
+		if block.Count == 0 {
+			tokenType = lspTokenUncovered
+		}
+
+		for line := block.StartLine; line <= block.EndLine && line <= len(lines); line++ {
+			startCol, endCol := 1, len(lines[line-1])+1
+			if line == block.StartLine {
+				startCol = block.StartCol
+			} else {
+				// skip indent of continuation lines
+				startCol += len(lines[line-1]) - len(strings.TrimLeft(lines[line-1], " \t"))
+			}
+			if line == block.EndLine {
+				endCol = block.EndCol
+			}
+
+			start, end := getUTF16Column(lines, line, startCol), getUTF16Column(lines, line, endCol)
+			if end > start {
+				tokens = append(tokens, lspToken{line: line - 1, start: start, length: end - start, tokenType: tokenType})
+			}
+		}
+	}
+	sort.SliceStable(tokens, func(i, j int) bool {
+		if tokens[i].line != tokens[j].line {
+			return tokens[i].line < tokens[j].line
+		}
+		return tokens[i].start < tokens[j].start
+	})
+
+	result := make([]uint32, 0, len(tokens)*5)
+	prevLine, prevEnd, prevStart := 0, 0, 0
+	for i, token := range tokens {
+		if i > 0 && token.line == prevLine && token.start < prevEnd {
+			// overlapped blocks are not allowed in semantic tokens
+			continue
+		}
+
+		deltaStart := token.start
+		if i > 0 && token.line == prevLine {
+			deltaStart = token.start - prevStart
+		}
+		result = append(result, uint32(token.line-prevLine), uint32(deltaStart), uint32(token.length), uint32(token.tokenType), 0)
+		prevLine, prevStart, prevEnd = token.line, token.start, token.start+token.length
+	}
+
+	return result
+}
+
+// getUTF16Column - convert byte column (from 1) of cover profile to LSP character offset in UTF-16 code units (from 0)
+func getUTF16Column(lines []string, line, col int) int {
+	if line < 1 || line > len(lines) {
+		return 0
+	}
+
+	text := lines[line-1]
+	if col < 1 {
+		col = 1
+	}
+	if col-1 < len(text) {
+		text = text[:col-1]
+	}
+
+	result := 0
+	for _, r := range text {
+		if r > 0xFFFF {
+			// surrogate pair
+			result += 2
+		} else {
+			result++
+		}
+	}
+
+	return result
+}
+
+// uriToPath - file system path from "file://" URI, empty for other URIs
+func uriToPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return ""
+	}
+
+	return filepath.FromSlash(parsed.Path)
+}
+
+// pathToURI - "file://" URI from absolute path
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
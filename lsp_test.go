@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+func lspRequest(t *testing.T, id int, method string, params interface{}) string {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method}
+	if id > 0 {
+		msg["id"] = id
+	}
+	if params != nil {
+		msg["params"] = params
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func Test_readLSPMessage(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("Content-Length: 2\r\nContent-Type: application/vscode-jsonrpc\r\n\r\n{}Content-Length: 4\r\n\r\nnull"))
+	for _, want := range []string{"{}", "null"} {
+		body, err := readLSPMessage(r)
+		if err != nil || string(body) != want {
+			t.Errorf("readLSPMessage() = %q, %v, want %q", body, err, want)
+		}
+	}
+
+	if _, err := readLSPMessage(bufio.NewReader(strings.NewReader("Content-Type: x\r\n\r\n{}"))); err == nil {
+		t.Errorf("readLSPMessage() without Content-Length: error expected")
+	}
+}
+
+func Test_getUTF16Column(t *testing.T) {
+	lines := []string{"abc", "ф := 1", "x := \"😀\" + y"}
+	tests := []struct {
+		line, col, want int
+	}{
+		{line: 1, col: 1, want: 0},
+		{line: 1, col: 3, want: 2},
+		{line: 1, col: 10, want: 3},
+		{line: 2, col: 4, want: 2},
+		{line: 3, col: 11, want: 8},
+		{line: 4, col: 1, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := getUTF16Column(lines, tt.line, tt.col); got != tt.want {
+			t.Errorf("getUTF16Column(%d, %d) = %d, want %d", tt.line, tt.col, got, tt.want)
+		}
+	}
+}
+
+func Test_getLSPSemanticTokens(t *testing.T) {
+	file := carpet.FileCover{
+		Profile: &cover.Profile{FileName: "a.go", Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 15, EndLine: 5, EndCol: 3, NumStmt: 2, Count: 1},
+			{StartLine: 5, StartCol: 3, EndLine: 7, EndCol: 2, NumStmt: 1, Count: 0},
+			{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 2, NumStmt: 0, Count: 0},
+		}},
+		Content: []byte("package a\n\nfunc a() int {\n\tx := 1\n\tif x > 0 {\n\t\treturn 1\n\t}\n\treturn 0\n}\n"),
+	}
+
+	want := []uint32{
+		3, 1, 6, lspTokenCovered, 0, // "x := 1"
+		1, 1, 1, lspTokenCovered, 0, // "i"
+		0, 1, 9, lspTokenUncovered, 0, // "f x > 0 {"
+		1, 2, 8, lspTokenUncovered, 0, // "return 1"
+	}
+	if got := getLSPSemanticTokens(file); !reflect.DeepEqual(got, want) {
+		t.Errorf("getLSPSemanticTokens():\ngot : %v\nwant: %v", got, want)
+	}
+
+	diagnostics := getLSPDiagnostics(file)
+	wantRange := lspRange{Start: lspPosition{Line: 4, Character: 2}, End: lspPosition{Line: 6, Character: 1}}
+	if len(diagnostics) != 1 || diagnostics[0].Range != wantRange || diagnostics[0].Severity != lspSeverityHint {
+		t.Errorf("getLSPDiagnostics() = %+v", diagnostics)
+	}
+}
+
+func Test_lspServer(t *testing.T) {
+	dir := t.TempDir()
+	sourceName := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(sourceName, []byte("package a\n\nfunc a() {\n\tprintln()\n}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	profile := fmt.Sprintf("mode: set\n%s:3.10,5.2 1 0\n", sourceName)
+	if err := os.WriteFile(filepath.Join(dir, "cover.out"), []byte(profile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	input := "Content-Length: 5\r\n\r\n{bad}" +
+		lspRequest(t, 1, "initialize", map[string]interface{}{"rootUri": pathToURI(dir), "capabilities": map[string]interface{}{}}) +
+		lspRequest(t, 0, "initialized", map[string]interface{}{}) +
+		lspRequest(t, 2, "textDocument/semanticTokens/full", map[string]interface{}{"textDocument": map[string]string{"uri": pathToURI(sourceName)}}) +
+		lspRequest(t, 3, "textDocument/hover", map[string]interface{}{}) +
+		lspRequest(t, 4, "shutdown", nil) +
+		lspRequest(t, 0, "exit", nil)
+
+	out := &bytes.Buffer{}
+	if err := newLSPServer("cover.out", strings.NewReader(input), out).serve(); err != nil {
+		t.Fatalf("serve(): %v", err)
+	}
+
+	messages := []map[string]interface{}{}
+	r := bufio.NewReader(out)
+	for {
+		body, err := readLSPMessage(r)
+		if err != nil {
+			break
+		}
+		msg := map[string]interface{}{}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if len(messages) != 6 {
+		t.Fatalf("got %d messages, want 6: %v", len(messages), messages)
+	}
+	if code := fmt.Sprint(messages[0]["error"]); !strings.Contains(code, "code:-32700") || messages[0]["id"] != nil {
+		t.Errorf("not valid JSON: parse error expected, got %v", messages[0])
+	}
+	if messages[2]["method"] != "textDocument/publishDiagnostics" || !strings.Contains(fmt.Sprint(messages[2]["params"]), "not covered by tests (1 statements)") {
+		t.Errorf("publishDiagnostics: %v", messages[2])
+	}
+	if got := fmt.Sprint(messages[3]["result"]); got != "map[data:[2 9 1 1 0 1 1 9 1 0 1 0 1 1 0]]" {
+		t.Errorf("semanticTokens/full: %v", got)
+	}
+	if _, ok := messages[4]["error"]; !ok {
+		t.Errorf("unknown method: error expected, got %v", messages[4])
+	}
+	if result, ok := messages[5]["result"]; !ok || result != nil {
+		t.Errorf("shutdown: got %v", messages[5])
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strings"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+// coverBaseline - profile blocks from previous run (for example from master branch) by file name from profile
+type coverBaseline map[string][]cover.ProfileBlock
+
+func loadCoverBaseline(coverFileName string) (coverBaseline, error) {
+	coverProfile, err := cover.ParseProfiles(coverFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := coverBaseline{}
+	for _, fileProfile := range coverProfile {
+		result[fileProfile.FileName] = append(result[fileProfile.FileName], fileProfile.Blocks...)
+	}
+
+	return result, nil
+}
+
+// getStat - get coverage of files in baseline, false if no one of files exists in baseline
+func (baseline coverBaseline) getStat(profileFileNames ...string) (float64, bool) {
+	blocks := []cover.ProfileBlock{}
+	found := false
+	for _, fileName := range profileFileNames {
+		if fileBlocks, ok := baseline[fileName]; ok {
+			blocks = append(blocks, fileBlocks...)
+			found = true
+		}
+	}
+
+	if !found {
+		return 0, false
+	}
+
+	return carpet.Coverage(blocks), true
+}
+
+// getDelta - format difference with baseline coverage with arrow
+func (baseline coverBaseline) getDelta(stat float64, profileFileNames ...string) string {
+	if baseline == nil {
+		return ""
+	}
+
+	baseStat, ok := baseline.getStat(profileFileNames...)
+	if !ok {
+		return "new"
+	}
+
+	delta := stat - baseStat
+	switch {
+	case math.Abs(delta) < 0.05:
+		return "→ 0.0%"
+	case delta > 0:
+		return fmt.Sprintf("↑ +%.1f%%", delta)
+	default:
+		return fmt.Sprintf("↓ %.1f%%", delta)
+	}
+}
+
+// markdownRenderer - collects files and writes report with summary table and uncovered snippets in the end
+type markdownRenderer struct {
+	title    string
+	baseline coverBaseline
+	files    []carpet.FileCover
+}
+
+func (r *markdownRenderer) BeginReport(io.Writer) error { return nil }
+
+func (r *markdownRenderer) BeginFile(_ io.Writer, file carpet.FileCover) error {
+	r.files = append(r.files, file)
+	return nil
+}
+
+func (r *markdownRenderer) BeginRange(io.Writer, carpet.TextRange) error { return nil }
+func (r *markdownRenderer) Segment(io.Writer, carpet.Segment) error      { return nil }
+func (r *markdownRenderer) EndRange(io.Writer) error                     { return nil }
+func (r *markdownRenderer) EndFile(io.Writer) error                      { return nil }
+func (r *markdownRenderer) Summary(io.Writer, carpet.Summary) error      { return nil }
+
+func (r *markdownRenderer) EndReport(w io.Writer) error {
+	_, err := io.WriteString(w, getMarkdownReport(r.title, r.files, r.baseline))
+	return err
+}
+
+// writeMarkdownReport - write full markdown report: own files, heatmap, risky functions, dependencies and failures
+func writeMarkdownReport(w io.Writer, files, deps []carpet.FileCover, failures []testFailure, baseline coverBaseline, config Config) error {
+	report := &bytes.Buffer{}
+	if err := carpet.Render(report, &markdownRenderer{title: "Coverage", baseline: baseline}, files, carpet.RenderOptions{WithoutSource: true}); err != nil {
+		return err
+	}
+	if config.heatmap > 0 {
+		report.WriteString("\n" + getMarkdownHeatmap(files, config.heatmap))
+	}
+	if config.risk > 0 {
+		report.WriteString("\n" + getMarkdownRisk(files, config.risk))
+	}
+	if config.blame > 0 {
+		report.WriteString("\n" + getMarkdownBlame(files, config.blame, getGitBlame))
+	}
+	if len(deps) > 0 {
+		report.WriteString("\n" + getMarkdownReport("Dependencies coverage", deps, baseline))
+	}
+	if len(failures) > 0 {
+		report.WriteString("\n" + getMarkdownFailures(failures))
+	}
+
+	_, err := w.Write(report.Bytes())
+	return err
+}
+
+// getMarkdownReport - report for pull-request comments: summary table and uncovered snippets
+func getMarkdownReport(title string, files []carpet.FileCover, baseline coverBaseline) string {
+	result := &bytes.Buffer{}
+
+	allBlocks := []cover.ProfileBlock{}
+	allFileNames := []string{}
+	packages := []string{}
+	packageFiles := map[string][]carpet.FileCover{}
+	for _, file := range files {
+		pkg := path.Dir(strings.TrimLeft(file.Profile.FileName, "_"))
+		if _, ok := packageFiles[pkg]; !ok {
+			packages = append(packages, pkg)
+		}
+		packageFiles[pkg] = append(packageFiles[pkg], file)
+		allBlocks = append(allBlocks, file.Profile.Blocks...)
+		allFileNames = append(allFileNames, file.Profile.FileName)
+	}
+
+	stat := carpet.Coverage(allBlocks)
+	fmt.Fprintf(result, "## %s: %.1f%% of statements", title, stat)
+	if delta := baseline.getDelta(stat, allFileNames...); delta != "" {
+		fmt.Fprintf(result, " (%s)", delta)
+	}
+	result.WriteString("\n\n")
+
+	if baseline != nil {
+		result.WriteString("| Package | File | Coverage | Δ |\n|---|---|---:|---:|\n")
+	} else {
+		result.WriteString("| Package | File | Coverage |\n|---|---|---:|\n")
+	}
+
+	for _, pkg := range packages {
+		pkgBlocks := []cover.ProfileBlock{}
+		pkgFileNames := []string{}
+		for _, file := range packageFiles[pkg] {
+			pkgBlocks = append(pkgBlocks, file.Profile.Blocks...)
+			pkgFileNames = append(pkgFileNames, file.Profile.FileName)
+		}
+
+		pkgStat := carpet.Coverage(pkgBlocks)
+		writeMarkdownRow(result, baseline, "`"+pkg+"`", "**total**", pkgStat, pkgFileNames...)
+		for _, file := range packageFiles[pkg] {
+			fileStat := carpet.Coverage(file.Profile.Blocks)
+			writeMarkdownRow(result, baseline, "", path.Base(file.Profile.FileName), fileStat, file.Profile.FileName)
+		}
+	}
+
+	for _, file := range files {
+		lineRanges := carpet.UncoveredLineRanges(file.Profile.Blocks)
+		if len(lineRanges) == 0 {
+			continue
+		}
+
+		rangesStr := make([]string, 0, len(lineRanges))
+		for _, lineRange := range lineRanges {
+			rangesStr = append(rangesStr, lineRange.String())
+		}
+
+		fmt.Fprintf(result, "\n<details>\n<summary><code>%s</code> - %.1f%%, uncovered lines: %s</summary>\n\n",
+			strings.TrimLeft(file.Profile.FileName, "_"),
+			carpet.Coverage(file.Profile.Blocks),
+			strings.Join(rangesStr, ", "),
+		)
+
+		lines := strings.Split(string(file.Content), "\n")
+		for _, lineRange := range lineRanges {
+			snippet := &strings.Builder{}
+			for line := lineRange.Begin; line <= lineRange.End && line <= len(lines); line++ {
+				snippet.WriteString(lines[line-1] + "\n")
+			}
+			fence := getMarkdownFence(snippet.String())
+			fmt.Fprintf(result, "Lines %s:\n\n%sgo\n%s%s\n\n", lineRange, fence, snippet, fence)
+		}
+		result.WriteString("</details>\n")
+	}
+
+	return result.String()
+}
+
+func writeMarkdownRow(result *bytes.Buffer, baseline coverBaseline, pkg, file string, stat float64, profileFileNames ...string) {
+	fmt.Fprintf(result, "| %s | %s | %.1f%% |", pkg, file, stat)
+	if baseline != nil {
+		fmt.Fprintf(result, " %s |", baseline.getDelta(stat, profileFileNames...))
+	}
+	result.WriteString("\n")
+}
+
+// getMarkdownHeatmap - table with the hottest blocks
+func getMarkdownHeatmap(files []carpet.FileCover, limit int) string {
+	result := &bytes.Buffer{}
+	result.WriteString("## Hottest blocks\n\n| Count | Position | Source |\n|---:|---|---|\n")
+	for _, block := range getHottestBlocks(files, limit) {
+		fmt.Fprintf(result, "| %d | `%s:%d-%d` | %s |\n", block.Count, block.fileName, block.StartLine, block.EndLine,
+			getMarkdownCodeSpan(strings.ReplaceAll(block.line, "|", "\\|")))
+	}
+
+	return result.String()
+}
+
+// getMarkdownRisk - table with the riskiest functions by CRAP score
+func getMarkdownRisk(files []carpet.FileCover, limit int) string {
+	result := &bytes.Buffer{}
+	result.WriteString("## Risky functions\n\n| Function | Position | Complexity | Coverage | CRAP |\n|---|---|---:|---:|---:|\n")
+	for i, fn := range getFuncsRisk(files) {
+		if i >= limit {
+			break
+		}
+		fmt.Fprintf(result, "| `%s` | `%s:%d` | %d | %.1f%% | %.1f |\n", fn.Name, fn.FileName, fn.StartLine, fn.Complexity, fn.Coverage, fn.CRAP)
+	}
+
+	return result.String()
+}
+
+// getMarkdownFailures - section with output of failed tests
+func getMarkdownFailures(failures []testFailure) string {
+	result := &bytes.Buffer{}
+	fmt.Fprintf(result, "## %d packages failed\n", len(failures))
+	for _, failure := range failures {
+		output := failure.result.output
+		fmt.Fprintf(result, "\n<details>\n<summary><code>%s</code>: %s", failure.path, failure.err)
+		if failedTests := failure.result.failedTests(); len(failedTests) > 0 {
+			fmt.Fprintf(result, ", failed tests: %s", strings.Join(failedTests, ", "))
+		}
+		fence := getMarkdownFence(string(output))
+		fmt.Fprintf(result, "</summary>\n\n%s\n%s", fence, output)
+		if len(output) > 0 && output[len(output)-1] != '\n' {
+			result.WriteString("\n")
+		}
+		result.WriteString(fence + "\n\n</details>\n")
+	}
+
+	return result.String()
+}
+
+// getMarkdownFence - fence of code block which is longer than the longest run of backticks in content
+func getMarkdownFence(content string) string {
+	longest := getLongestBacktickRun(content)
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+// getMarkdownCodeSpan - inline code, backtick string of span is longer than the longest backtick run in text
+func getMarkdownCodeSpan(text string) string {
+	backticks := strings.Repeat("`", getLongestBacktickRun(text)+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+
+	return backticks + text + backticks
+}
+
+func getLongestBacktickRun(content string) int {
+	longest, current := 0, 0
+	for _, char := range content {
+		if char != '`' {
+			current = 0
+			continue
+		}
+		current++
+		if current > longest {
+			longest = current
+		}
+	}
+
+	return longest
+}
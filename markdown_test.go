@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+func Test_coverBaseline_getDelta(t *testing.T) {
+	baseline, err := loadCoverBaseline("./testdata/cover_00.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	halfCovered := coverBaseline{"file.go": {{NumStmt: 1, Count: 1}, {NumStmt: 1, Count: 0}}}
+
+	tests := []struct {
+		name      string
+		baseline  coverBaseline
+		stat      float64
+		fileNames []string
+		want      string
+	}{
+		{name: "without baseline", baseline: nil, stat: 50, fileNames: []string{"_./testdata/file_00.golang"}, want: ""},
+		{name: "new file", baseline: baseline, stat: 50, fileNames: []string{"_./testdata/new.golang"}, want: "new"},
+		{name: "up", baseline: halfCovered, stat: 75, fileNames: []string{"file.go"}, want: "↑ +25.0%"},
+		{name: "down", baseline: baseline, stat: 75, fileNames: []string{"_./testdata/file_00.golang"}, want: "↓ -25.0%"},
+		{name: "same", baseline: baseline, stat: 100, fileNames: []string{"_./testdata/file_00.golang", "_./testdata/file_01.golang"}, want: "→ 0.0%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.baseline.getDelta(tt.stat, tt.fileNames...); got != tt.want {
+				t.Errorf("getDelta() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getMarkdownReport(t *testing.T) {
+	files := []carpet.FileCover{{
+		Profile: &cover.Profile{
+			FileName: "example.com/pkg/file.go",
+			Mode:     "count",
+			Blocks: []cover.ProfileBlock{
+				{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 10, NumStmt: 1, Count: 1},
+				{StartLine: 3, StartCol: 1, EndLine: 3, EndCol: 10, NumStmt: 1, Count: 0},
+			},
+		},
+		Content: []byte("package pkg\ncovered()\nnotCovered()\n"),
+	}}
+	baseline := coverBaseline{
+		"example.com/pkg/file.go": {{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 10, NumStmt: 1, Count: 0}},
+	}
+
+	got := getMarkdownReport("Coverage", files, baseline)
+	want := "## Coverage: 50.0% of statements (↑ +50.0%)\n\n" +
+		"| Package | File | Coverage | Δ |\n|---|---|---:|---:|\n" +
+		"| `example.com/pkg` | **total** | 50.0% | ↑ +50.0% |\n" +
+		"|  | file.go | 50.0% | ↑ +50.0% |\n" +
+		"\n<details>\n<summary><code>example.com/pkg/file.go</code> - 50.0%, uncovered lines: 3</summary>\n\n" +
+		"Lines 3:\n\n```go\nnotCovered()\n```\n\n</details>\n"
+	if got != want {
+		t.Errorf("getMarkdownReport():\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = getMarkdownReport("Coverage", files, nil)
+	if got[:len("## Coverage: 50.0% of statements\n\n| Package | File | Coverage |\n")] != "## Coverage: 50.0% of statements\n\n| Package | File | Coverage |\n" {
+		t.Errorf("getMarkdownReport() without baseline:\n%s", got)
+	}
+}
+
+func Test_getMarkdownFailures(t *testing.T) {
+	got := getMarkdownFailures([]testFailure{{
+		path:   "./a",
+		err:    errors.New("exit status 1"),
+		result: goTestResult{output: []byte("FAIL"), tests: []testResult{{name: "TestA", action: "fail"}}},
+	}})
+	want := "## 1 packages failed\n\n<details>\n<summary><code>./a</code>: exit status 1, failed tests: TestA</summary>\n\n```\nFAIL\n```\n\n</details>\n"
+	if got != want {
+		t.Errorf("getMarkdownFailures():\ngot :\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func Test_getMarkdownFence(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{content: "FAIL\n", want: "```"},
+		{content: "s := `raw` + \"``\"\n", want: "```"},
+		{content: "// ```go\n", want: "````"},
+		{content: "`````\n```", want: "``````"},
+	}
+
+	for _, tt := range tests {
+		if got := getMarkdownFence(tt.content); got != tt.want {
+			t.Errorf("getMarkdownFence(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+
+	got := getMarkdownFailures([]testFailure{{path: "./a", err: errors.New("exit status 1"), result: goTestResult{output: []byte("```\n")}}})
+	if !strings.Contains(got, "````\n```\n````\n") {
+		t.Errorf("getMarkdownFailures() with backticks in output:\n%s", got)
+	}
+}
+
+func Test_getMarkdownCodeSpan(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "x := 1", want: "`x := 1`"},
+		{text: "s := `raw`", want: "`` s := `raw` ``"},
+		{text: "a + \"``\" + b", want: "```a + \"``\" + b```"},
+	}
+
+	for _, tt := range tests {
+		if got := getMarkdownCodeSpan(tt.text); got != tt.want {
+			t.Errorf("getMarkdownCodeSpan(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	files := []carpet.FileCover{{
+		Profile: &cover.Profile{FileName: "a.go", Blocks: []cover.ProfileBlock{{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 20, NumStmt: 1, Count: 7}}},
+		Content: []byte("s += `a|b` + x\n"),
+	}}
+	if got := getMarkdownHeatmap(files, 1); !strings.Contains(got, "| 7 | `a.go:1-1` | ``s += `a\\|b` + x`` |\n") {
+		t.Errorf("getMarkdownHeatmap() with backticks in source:\n%s", got)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+var reTestName = regexp.MustCompile(`^(Test|Example|Fuzz)\S*$`)
+
+// listTests - get names of top-level tests of package matched by pattern, on error returns output of go test
+func listTests(path, pattern string, goTestArgs []string) (result []string, output []byte, err error) {
+	args := []string{"test", "-list", pattern}
+	args = append(args, goTestArgs...)
+	args = append(args, path)
+	out, err := exec.Command("go", args...).CombinedOutput() // #nosec
+	if err != nil {
+		return nil, out, fmt.Errorf("failed to list tests in %s: %s", path, err)
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); reTestName.MatchString(line) {
+			result = append(result, line)
+		}
+	}
+
+	return result, nil, nil
+}
+
+// testCover - coverage of one test
+type testCover struct {
+	path  string
+	name  string
+	files []carpet.FileCover
+}
+
+func (test testCover) String() string {
+	return test.path + ":" + test.name
+}
+
+// testsCover - coverage attributed to tests
+type testsCover struct {
+	tests []testCover
+}
+
+// runTestsSeparately - run each top-level test of package with own profile,
+// returns coverage merged from all tests, or coverage of only one test if -tests-only option is set
+func runTestsSeparately(path, coverFileName string, goTestArgs []string, config Config, attribution *testsCover) (files []carpet.FileCover, failures []testFailure, err error) {
+	testNames, output, err := listTests(path, config.testsPattern, goTestArgs)
+	if err != nil {
+		// package which failed to build is reported with failed packages
+		return nil, []testFailure{{path: path, err: err, result: goTestResult{output: output}}}, nil
+	}
+
+	merged := carpet.Merged{}
+	for _, testName := range testNames {
+		args := append(append([]string{}, goTestArgs...), "-run", "^"+regexp.QuoteMeta(testName)+"$")
+		if testResult, errTest := runGoTest(path, coverFileName, config.coverMode, args, false); errTest != nil {
+			failures = append(failures, testFailure{path: path + ":" + testName, err: errTest, result: testResult})
+		}
+
+		testFiles, err := carpet.LoadFiles(coverFileName, carpet.Filter{Files: config.filesFilter})
+		if err != nil {
+			return nil, failures, err
+		}
+
+		test := testCover{path: path, name: testName, files: testFiles}
+		attribution.tests = append(attribution.tests, test)
+		if config.testsOnly == "" || config.testsOnly == testName || config.testsOnly == test.String() {
+			merged.Add(testFiles...)
+		}
+	}
+
+	for _, file := range merged.Files {
+		if !config.getFilter().IsSkipped(file.Profile.Blocks) {
+			files = append(files, file)
+		}
+	}
+
+	return files, failures, nil
+}
+
+// getTestsForLine - get tests which executed the line of file, file is matched by suffix of path (by whole names of directories)
+func (attribution testsCover) getTestsForLine(fileName string, line int) (result []string) {
+	for _, test := range attribution.tests {
+		if test.coversLine(fileName, line) {
+			result = append(result, test.String())
+		}
+	}
+
+	return result
+}
+
+func (test testCover) coversLine(fileName string, line int) bool {
+	for _, file := range test.files {
+		if !isPathHasSuffix(file.FileName, fileName) && !isPathHasSuffix(file.Profile.FileName, fileName) {
+			continue
+		}
+		for _, block := range file.Profile.Blocks {
+			if block.Count > 0 && block.StartLine <= line && line <= block.EndLine {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isPathHasSuffix - path ends with suffix on boundary of path elements: "pkg/a.go" ends with "a.go", but "data.go" is not
+func isPathHasSuffix(path, suffix string) bool {
+	path, suffix = filepath.ToSlash(path), filepath.ToSlash(suffix)
+	return path == suffix || strings.HasSuffix(path, "/"+strings.TrimPrefix(suffix, "/"))
+}
+
+// testStat - statements covered by test, unique - covered only by this test
+type testStat struct {
+	name    string
+	covered int
+	unique  int
+}
+
+// getTestsStat - get covered statements by each test, tests without unique statements are candidates for redundant tests
+func (attribution testsCover) getTestsStat() (result []testStat) {
+	type fileBlock struct {
+		fileName string
+		pos      carpet.BlockPos
+	}
+
+	coveredBy := map[fileBlock]int{}
+	for _, test := range attribution.tests {
+		for _, block := range test.getCoveredBlocks() {
+			coveredBy[fileBlock{block.fileName, carpet.GetBlockPos(block.ProfileBlock)}]++
+		}
+	}
+
+	for _, test := range attribution.tests {
+		stat := testStat{name: test.String()}
+		for _, block := range test.getCoveredBlocks() {
+			stat.covered += block.NumStmt
+			if coveredBy[fileBlock{block.fileName, carpet.GetBlockPos(block.ProfileBlock)}] == 1 {
+				stat.unique += block.NumStmt
+			}
+		}
+		result = append(result, stat)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].unique > result[j].unique })
+
+	return result
+}
+
+type fileProfileBlock struct {
+	cover.ProfileBlock
+	fileName string
+}
+
+func (test testCover) getCoveredBlocks() (result []fileProfileBlock) {
+	for _, file := range test.files {
+		for _, block := range file.Profile.Blocks {
+			if block.Count > 0 {
+				result = append(result, fileProfileBlock{ProfileBlock: block, fileName: file.FileName})
+			}
+		}
+	}
+
+	return result
+}
+
+// getTestsReport - table with statements covered by each test
+func getTestsReport(attribution testsCover, config Config) string {
+	stats := attribution.getTestsStat()
+	if len(stats) == 0 {
+		return ""
+	}
+
+	nameWidth := len("Test")
+	for _, stat := range stats {
+		if len(stat.name) > nameWidth {
+			nameWidth = len(stat.name)
+		}
+	}
+
+	header := fmt.Sprintf("%-*s %10s %10s", nameWidth, "Test", "statements", "unique")
+	result := "\n" + getColorHeader(header, true, config)
+	for _, stat := range stats {
+		result += fmt.Sprintf("%-*s %10d %10d\n", nameWidth, stat.name, stat.covered, stat.unique)
+	}
+
+	return result
+}
+
+// getTestsForLineReport - list of tests which executed the line
+func getTestsForLineReport(attribution testsCover, fileLine string, config Config) (string, error) {
+	fileName, line, err := parseFileLine(fileLine)
+	if err != nil {
+		return "", err
+	}
+
+	tests := attribution.getTestsForLine(fileName, line)
+	result := "\n" + getColorHeader(fmt.Sprintf("Tests which executed %s: %d", fileLine, len(tests)), true, config)
+	for _, test := range tests {
+		result += test + "\n"
+	}
+
+	return result, nil
+}
+
+// parseFileLine - "file.go:120" -> "file.go", 120
+func parseFileLine(fileLine string) (string, int, error) {
+	i := strings.LastIndex(fileLine, ":")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("%q is not in file:line format", fileLine)
+	}
+
+	line, err := strconv.Atoi(fileLine[i+1:])
+	if err != nil || line <= 0 {
+		return "", 0, fmt.Errorf("%q is not in file:line format", fileLine)
+	}
+
+	return fileLine[:i], line, nil
+}
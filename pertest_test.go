@@ -0,0 +1,131 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+func Test_listTests(t *testing.T) {
+	tests, _, err := listTests(".", "^Test_listTests$|^Test_parseFileLine$", nil)
+	if err != nil {
+		t.Fatalf("listTests() failed: %s", err)
+	}
+	if !reflect.DeepEqual(tests, []string{"Test_listTests", "Test_parseFileLine"}) {
+		t.Errorf("listTests() = %v", tests)
+	}
+
+	if _, output, err := listTests("./not exists dir", ".", nil); err == nil || len(output) == 0 {
+		t.Errorf("listTests() not got error or output: %q", output)
+	}
+}
+
+func Test_parseFileLine(t *testing.T) {
+	tests := []struct {
+		in       string
+		fileName string
+		line     int
+		wantErr  bool
+	}{
+		{in: "file.go:120", fileName: "file.go", line: 120},
+		{in: `C:\src\file.go:1`, fileName: `C:\src\file.go`, line: 1},
+		{in: "file.go", wantErr: true},
+		{in: ":10", wantErr: true},
+		{in: "file.go:0", wantErr: true},
+		{in: "file.go:abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			fileName, line, err := parseFileLine(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFileLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if fileName != tt.fileName || line != tt.line {
+				t.Errorf("parseFileLine() = %q, %d", fileName, line)
+			}
+		})
+	}
+}
+
+func getTestTestsCover() testsCover {
+	newFile := func(blocks ...cover.ProfileBlock) []carpet.FileCover {
+		return []carpet.FileCover{{
+			FileName: "/src/pkg/file.go",
+			Profile:  &cover.Profile{FileName: "example.com/pkg/file.go", Mode: "count", Blocks: blocks},
+		}}
+	}
+
+	return testsCover{tests: []testCover{
+		{path: "./pkg", name: "TestA", files: newFile(
+			cover.ProfileBlock{StartLine: 3, EndLine: 5, NumStmt: 2, Count: 1},
+			cover.ProfileBlock{StartLine: 7, EndLine: 8, NumStmt: 1, Count: 0},
+		)},
+		{path: "./pkg", name: "TestB", files: newFile(
+			cover.ProfileBlock{StartLine: 3, EndLine: 5, NumStmt: 2, Count: 1},
+			cover.ProfileBlock{StartLine: 7, EndLine: 8, NumStmt: 1, Count: 3},
+		)},
+		{path: "./pkg", name: "TestC", files: newFile(
+			cover.ProfileBlock{StartLine: 3, EndLine: 5, NumStmt: 2, Count: 0},
+			cover.ProfileBlock{StartLine: 7, EndLine: 8, NumStmt: 1, Count: 0},
+		)},
+	}}
+}
+
+func Test_testsCover_getTestsForLine(t *testing.T) {
+	attribution := getTestTestsCover()
+
+	if got := attribution.getTestsForLine("file.go", 4); !reflect.DeepEqual(got, []string{"./pkg:TestA", "./pkg:TestB"}) {
+		t.Errorf("1. getTestsForLine() = %v", got)
+	}
+	if got := attribution.getTestsForLine("pkg/file.go", 8); !reflect.DeepEqual(got, []string{"./pkg:TestB"}) {
+		t.Errorf("2. getTestsForLine() = %v", got)
+	}
+	if got := attribution.getTestsForLine("file.go", 6); len(got) != 0 {
+		t.Errorf("3. getTestsForLine() = %v", got)
+	}
+	if got := attribution.getTestsForLine("other.go", 4); len(got) != 0 {
+		t.Errorf("4. getTestsForLine() = %v", got)
+	}
+	if got := attribution.getTestsForLine("le.go", 4); len(got) != 0 {
+		t.Errorf("5. getTestsForLine() = %v", got)
+	}
+}
+
+func Test_getTestsReport(t *testing.T) {
+	attribution := getTestTestsCover()
+
+	wantStat := []testStat{
+		{name: "./pkg:TestB", covered: 3, unique: 1},
+		{name: "./pkg:TestA", covered: 2, unique: 0},
+		{name: "./pkg:TestC", covered: 0, unique: 0},
+	}
+	if got := attribution.getTestsStat(); !reflect.DeepEqual(got, wantStat) {
+		t.Errorf("getTestsStat() = %v", got)
+	}
+
+	got := getTestsReport(attribution, Config{plain: true})
+	want := "\n" +
+		"Test        statements     unique\n" +
+		"~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\n" +
+		"./pkg:TestB          3          1\n" +
+		"./pkg:TestA          2          0\n" +
+		"./pkg:TestC          0          0\n"
+	if got != want {
+		t.Errorf("getTestsReport():\ngot :\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := getTestsReport(testsCover{}, Config{plain: true}); got != "" {
+		t.Errorf("getTestsReport() for empty: %q", got)
+	}
+
+	lineReport, err := getTestsForLineReport(attribution, "file.go:8", Config{plain: true})
+	if err != nil || lineReport != "\nTests which executed file.go:8: 1\n~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\n./pkg:TestB\n" {
+		t.Errorf("getTestsForLineReport() = %q, %v", lineReport, err)
+	}
+	if _, err := getTestsForLineReport(attribution, "file.go", Config{}); err == nil {
+		t.Errorf("getTestsForLineReport() not got error")
+	}
+}
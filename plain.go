@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// values of -color option
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// isPlainOutput - output without ANSI colors: by -color option, NO_COLOR (https://no-color.org) or when stdout is not a terminal
+func isPlainOutput(colorMode string) (bool, error) {
+	switch colorMode {
+	case colorAlways:
+		return false, nil
+	case colorNever:
+		return true, nil
+	case colorAuto:
+		if os.Getenv("NO_COLOR") != "" {
+			return true, nil
+		}
+		fd := os.Stdout.Fd()
+		return !isatty.IsTerminal(fd) && !isatty.IsCygwinTerminal(fd), nil
+	default:
+		return false, fmt.Errorf("unknown color mode: %q, use one of: %s, %s, %s", colorMode, colorAuto, colorAlways, colorNever)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func Test_isPlainOutput(t *testing.T) {
+	if plain, err := isPlainOutput(colorAlways); plain || err != nil {
+		t.Errorf("isPlainOutput(always) failed")
+	}
+	if plain, err := isPlainOutput(colorNever); !plain || err != nil {
+		t.Errorf("isPlainOutput(never) failed")
+	}
+	if _, err := isPlainOutput("sometimes"); err == nil {
+		t.Errorf("isPlainOutput() not got error")
+	}
+
+	noColor, noColorExists := os.LookupEnv("NO_COLOR")
+	defer func() {
+		if noColorExists {
+			_ = os.Setenv("NO_COLOR", noColor)
+		} else {
+			_ = os.Unsetenv("NO_COLOR")
+		}
+	}()
+
+	if err := os.Setenv("NO_COLOR", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := isPlainOutput(colorAuto); !plain || err != nil {
+		t.Errorf("isPlainOutput(auto) with NO_COLOR failed")
+	}
+	if plain, err := isPlainOutput(colorAlways); plain || err != nil {
+		t.Errorf("isPlainOutput(always) with NO_COLOR failed")
+	}
+}
+
+func Test_renderFilesCover_plain(t *testing.T) {
+	fileProfile := &cover.Profile{
+		FileName: "filename.go",
+		Mode:     "count",
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 2, StartCol: 5, EndLine: 2, EndCol: 10, NumStmt: 1, Count: 1},
+			{StartLine: 3, StartCol: 8, EndLine: 4, EndCol: 3, NumStmt: 2, Count: 0},
+		},
+	}
+	fileContent := []byte("1 line\n123 green 456\n3 line red and other\n4 line\n\n6 line")
+
+	got := renderFileCover(fileProfile, fileContent, Config{plain: true})
+	want := "filename.go - 33.3%\n" +
+		"~~~~~~~~~~~~~~~~~~~\n" +
+		"  1 line\n" +
+		"+ 123 green 456\n" +
+		"- 3 line red and other\n" +
+		"- 4 line\n" +
+		"\n" +
+		"  6 line\n"
+	if string(got) != want {
+		t.Errorf("renderFilesCover() plain:\ngot :\n%s\nwant:\n%s", got, want)
+	}
+
+	got = renderFileCover(fileProfile, fileContent, Config{plain: true, funcFilter: []string{"fn"}})
+	if len(got) != 0 {
+		t.Errorf("renderFilesCover() plain with not exists func: %q", got)
+	}
+}
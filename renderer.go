@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mgutz/ansi"
+	"github.com/msoap/go-carpet/carpet"
+)
+
+// terminalRenderer - renders source with coverage in colors, with syntax highlighting or with line markers
+type terminalRenderer struct {
+	config Config
+	th     theme
+	file   carpet.FileCover
+	colors colorSegments
+	plain  carpet.PlainRenderer
+	syntax syntaxHighlighter
+}
+
+func newTerminalRenderer(config Config) *terminalRenderer {
+	return &terminalRenderer{config: config, th: config.getTheme()}
+}
+
+// getRenderOptions - options of rendering by -func and -summary options
+func (config Config) getRenderOptions() carpet.RenderOptions {
+	return carpet.RenderOptions{Funcs: config.funcFilter, WithoutSource: config.summary}
+}
+
+func (r *terminalRenderer) BeginReport(io.Writer) error { return nil }
+
+func (r *terminalRenderer) BeginFile(w io.Writer, file carpet.FileCover) error {
+	r.file = file
+
+	var fileNameDisplay string
+	if len(r.config.funcFilter) == 0 {
+		fileNameDisplay = fmt.Sprintf("%s - %.1f%%", strings.TrimLeft(file.Profile.FileName, "_"), file.Coverage())
+	} else {
+		fileNameDisplay = strings.TrimLeft(file.Profile.FileName, "_")
+	}
+
+	if r.config.summary {
+		_, err := io.WriteString(w, fileNameDisplay+"\n")
+		return err
+	}
+
+	if r.config.syntax && !r.config.plain {
+		r.syntax = newSyntaxHighlighter(file.Content, r.th, r.config)
+	}
+
+	_, err := io.WriteString(w, getColorHeader(fileNameDisplay, true, r.config))
+	return err
+}
+
+func (r *terminalRenderer) BeginRange(w io.Writer, textRange carpet.TextRange) error {
+	r.colors.beginRange(textRange)
+	switch {
+	case r.config.plain:
+		return r.plain.BeginRange(w, textRange)
+	case r.config.syntax:
+		r.syntax.beginRange()
+	}
+
+	return nil
+}
+
+func (r *terminalRenderer) Segment(w io.Writer, segment carpet.Segment) error {
+	switch {
+	case r.config.plain:
+		return r.plain.Segment(w, segment)
+	case r.config.syntax:
+		_, err := w.Write(r.syntax.segment(segment))
+		return err
+	}
+
+	color := ""
+	switch {
+	case segment.State == carpet.CoverCovered && r.config.heatmap > 0:
+		color = getHeatmapColor(getHeatNorm(segment.Count, r.config.heatmapMax), r.config)
+	case segment.State == carpet.CoverCovered:
+		color = r.th.getCoveredColor(segment.Norm, r.file.Profile.Mode, r.config)
+	case segment.State == carpet.CoverUncovered:
+		color = colorCode(r.th.Uncovered.get(r.config.colors256, r.config.trueColor))
+	}
+
+	return r.colors.write(w, segment, color)
+}
+
+func (r *terminalRenderer) EndRange(w io.Writer) error {
+	switch {
+	case r.config.plain:
+		return r.plain.EndRange(w)
+	case r.config.syntax:
+		_, err := w.Write(r.syntax.endRange())
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (r *terminalRenderer) EndFile(io.Writer) error { return nil }
+
+func (r *terminalRenderer) Summary(w io.Writer, summary carpet.Summary) error {
+	totalCoverage := fmt.Sprintf("Coverage: %.1f%% of statements, cover mode: %s", summary.Coverage, summary.Mode)
+	_, err := io.WriteString(w, getColorHeader(totalCoverage, false, r.config))
+	return err
+}
+
+func (r *terminalRenderer) EndReport(io.Writer) error { return nil }
+
+// colorSegments - writes segments of range in colors
+type colorSegments struct {
+	curColor string // color of current segment, empty for segment without color
+	rangeEnd int
+}
+
+func (c *colorSegments) beginRange(textRange carpet.TextRange) {
+	c.curColor, c.rangeEnd = "", textRange.End
+}
+
+// write - write segment in color, segment without color resets color of previous segment
+func (c *colorSegments) write(w io.Writer, segment carpet.Segment, color string) error {
+	result := []byte{}
+	text := segment.Text
+	switch {
+	case color != "":
+		result = append(result, color...)
+		// Add ansi color code in begin of each line (this fixed view in "less -R"), except tail of range
+		if segment.Offset+len(text) < c.rangeEnd {
+			text = reNewLine.ReplaceAllLiteral(text, []byte(ansi.ColorCode("reset")+"\n"+color))
+		}
+	case c.curColor != "":
+		result = append(result, ansi.ColorCode("reset")...)
+	}
+	c.curColor = color
+
+	_, err := w.Write(append(result, text...))
+	return err
+}
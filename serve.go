@@ -0,0 +1,454 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/msoap/go-carpet/carpet"
+)
+
+// watchInterval - interval of checking source files for changes in -serve mode
+const watchInterval = time.Second
+
+// coverServer - web UI with coverage from the last run of tests
+type coverServer struct {
+	config        Config
+	testDirs      []string
+	coverFileName string
+	goTestArgs    []string
+
+	mu      sync.Mutex
+	run     coverRun
+	updated time.Time // time of the last finished run, zero before the first run
+	running bool
+	pending bool // re-run was requested while tests are running
+	clients map[chan string]struct{}
+}
+
+func newCoverServer(config Config, testDirs []string, coverFileName string, goTestArgs []string) *coverServer {
+	return &coverServer{
+		config:        config,
+		testDirs:      testDirs,
+		coverFileName: coverFileName,
+		goTestArgs:    goTestArgs,
+		clients:       map[chan string]struct{}{},
+	}
+}
+
+// serve - serve coverage UI on address until interrupted, tests are re-run on demand or on change of source files
+func serve(config Config, testDirs []string, coverFileName string, goTestArgs []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	coverSrv := newCoverServer(config, testDirs, coverFileName, goTestArgs)
+	listener, err := net.Listen("tcp", getListenAddress(config.serve))
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           coverSrv.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		// cancel requests with server-sent events on shutdown
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Serve(listener) }()
+	log.Printf("serving coverage on http://%s/", listener.Addr())
+
+	go coverSrv.rerun()
+	go coverSrv.watch(ctx, watchInterval)
+
+	select {
+	case err = <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *coverServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/file", s.handleFile)
+	mux.HandleFunc("/run", s.handleRun)
+	mux.HandleFunc("/events", s.handleEvents)
+
+	listenHost, _, err := net.SplitHostPort(getListenAddress(s.config.serve))
+	if err != nil {
+		listenHost = ""
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAllowedHost(listenHost, r.Host) {
+			http.Error(w, "host is not allowed", http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// rerun - run tests and notify clients, concurrent requests are coalesced into one more run
+func (s *coverServer) rerun() {
+	s.mu.Lock()
+	if s.running {
+		s.pending = true
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+	s.broadcast("running")
+
+	for {
+		run := runCover(s.testDirs, s.coverFileName, s.goTestArgs, s.config, nil)
+
+		s.mu.Lock()
+		s.run, s.updated = run, time.Now()
+		if !s.pending {
+			s.running = false
+			s.mu.Unlock()
+			break
+		}
+		s.pending = false
+		s.mu.Unlock()
+	}
+
+	s.broadcast("update")
+}
+
+// watch - re-run tests when source files in directories of tests are changed
+func (s *coverServer) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastState := getSourcesState(s.testDirs)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if state := getSourcesState(s.testDirs); state != lastState {
+				lastState = state
+				go s.rerun()
+			}
+		}
+	}
+}
+
+// getSourcesState - names, sizes and modification times of Go files in directories
+func getSourcesState(dirs []string) string {
+	result := &strings.Builder{}
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(result, "%s\t%d\t%d\n", filepath.Join(dir, entry.Name()), info.Size(), info.ModTime().UnixNano())
+		}
+	}
+
+	return result.String()
+}
+
+func (s *coverServer) subscribe() chan string {
+	events := make(chan string, 1)
+	s.mu.Lock()
+	s.clients[events] = struct{}{}
+	s.mu.Unlock()
+
+	return events
+}
+
+func (s *coverServer) unsubscribe(events chan string) {
+	s.mu.Lock()
+	delete(s.clients, events)
+	s.mu.Unlock()
+}
+
+// broadcast - send event to all clients, slow clients lose intermediate events
+func (s *coverServer) broadcast(event string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for events := range s.clients {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// state - copy of the last run with status
+func (s *coverServer) state() (run coverRun, updated time.Time, running bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.run, s.updated, s.running
+}
+
+func (s *coverServer) handleRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !isSameOriginRequest(r) {
+		http.Error(w, "cross-origin request is not allowed", http.StatusForbidden)
+		return
+	}
+
+	go s.rerun()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// getListenAddress - address without host (like ":8080") is bound to localhost, source code is not exposed to network
+func getListenAddress(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || host != "" {
+		return address
+	}
+
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// isAllowedHost - server on loopback address serves only requests to local host names, it protects from DNS rebinding
+func isAllowedHost(listenHost, requestHost string) bool {
+	if listenHost != "localhost" && !net.ParseIP(listenHost).IsLoopback() {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(requestHost)
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(requestHost, "["), "]")
+	}
+
+	return strings.EqualFold(host, "localhost") || host == listenHost || net.ParseIP(host).IsLoopback()
+}
+
+// isSameOriginRequest - request is not sent from page of other site, by Sec-Fetch-Site and Origin headers of browsers,
+// requests without these headers are from other clients (like curl)
+func isSameOriginRequest(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return false
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	originURL, err := url.Parse(origin)
+
+	return err == nil && originURL.Host == r.Host
+}
+
+func (s *coverServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events := s.subscribe()
+	defer s.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-events:
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// servePackage - files of one package in the package tree
+type servePackage struct {
+	Name  string
+	Files []carpet.FileStat
+}
+
+// getPackagesTree - files grouped by package, sorted by name
+func getPackagesTree(files []carpet.FileCover) []servePackage {
+	packages := map[string][]carpet.FileStat{}
+	for _, stat := range carpet.GetFileStats(files) {
+		packages[stat.Package] = append(packages[stat.Package], stat)
+	}
+
+	result := make([]servePackage, 0, len(packages))
+	for name, stats := range packages {
+		sort.Slice(stats, func(i, j int) bool { return stats[i].FileName < stats[j].FileName })
+		result = append(result, servePackage{Name: name, Files: stats})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result
+}
+
+func (s *coverServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	run, updated, running := s.state()
+	failures := make([]string, 0, len(run.failures))
+	for _, failure := range run.failures {
+		failures = append(failures, fmt.Sprintf("%s: %s\n%s", failure.path, failure.err, failure.result.output))
+	}
+
+	s.writePage(w, indexTemplate, map[string]interface{}{
+		"Title":    "Coverage",
+		"Running":  running,
+		"Updated":  updated,
+		"Summary":  carpet.GetSummary(run.files),
+		"Packages": getPackagesTree(run.files),
+		"Funcs":    getFuncsRisk(run.files),
+		"Failures": failures,
+	})
+}
+
+func (s *coverServer) handleFile(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	run, updated, running := s.state()
+
+	for _, file := range run.files {
+		if strings.TrimLeft(file.Profile.FileName, "_") != name {
+			continue
+		}
+
+		source := &bytes.Buffer{}
+		if err := carpet.RenderFiles(source, &htmlRenderer{}, []carpet.FileCover{file}, carpet.RenderOptions{Funcs: s.config.funcFilter}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		s.writePage(w, fileTemplate, map[string]interface{}{
+			"Title":   fmt.Sprintf("%s - %.1f%%", name, file.Coverage()),
+			"Running": running,
+			"Updated": updated,
+			"Source":  template.HTML(source.String()), // source is escaped by htmlRenderer
+		})
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+func (s *coverServer) writePage(w http.ResponseWriter, content *template.Template, data map[string]interface{}) {
+	buf := &bytes.Buffer{}
+	if err := content.Execute(buf, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Print(err)
+	}
+}
+
+// htmlRenderer - renders source of file for page of file, name of file is in header of page
+type htmlRenderer struct {
+	carpet.HTMLRenderer
+}
+
+func (r *htmlRenderer) BeginFile(io.Writer, carpet.FileCover) error { return nil }
+
+const pageHeaderHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} - go-carpet</title>
+<style>
+body { font-family: sans-serif; margin: 1em 2em; }
+table { border-collapse: collapse; }
+td, th { padding: 2px 8px; text-align: left; }
+td.num { text-align: right; }
+` + carpet.HTMLStyle + `.status { color: #888; }
+</style>
+<script>
+const events = new EventSource("/events");
+events.addEventListener("running", () => { document.getElementById("status").textContent = "running tests..."; });
+events.addEventListener("update", () => { location.reload(); });
+function rerun() { fetch("/run", {method: "POST"}); }
+</script>
+</head>
+<body>
+<p class="status"><a href="/">index</a> |
+<button onclick="rerun()">re-run tests</button>
+<span id="status">{{if .Running}}running tests...{{else if not .Updated.IsZero}}updated at {{.Updated.Format "15:04:05"}}{{end}}</span></p>
+<h1>{{.Title}}</h1>
+`
+
+const pageFooterHTML = `</body>
+</html>
+`
+
+var indexTemplate = template.Must(template.New("index").Parse(pageHeaderHTML + `
+{{if .Summary.Files}}<p>Coverage: {{printf "%.1f" .Summary.Coverage}}% of statements, cover mode: {{.Summary.Mode}}</p>{{end}}
+{{range .Packages}}
+<h3>{{.Name}}</h3>
+<table>
+{{range .Files}}<tr><td><a href="/file?name={{.FileName}}">{{.FileName}}</a></td><td class="num">{{printf "%.1f" .Coverage}}%</td><td class="num">{{.Covered}}/{{.Statements}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Funcs}}
+<h2>Functions</h2>
+<table>
+<tr><th>Function</th><th>File</th><th>Complexity</th><th>Coverage</th><th>CRAP</th></tr>
+{{range .Funcs}}<tr><td>{{.Name}}</td><td><a href="/file?name={{.FileName}}">{{.FileName}}:{{.StartLine}}</a></td><td class="num">{{.Complexity}}</td><td class="num">{{printf "%.1f" .Coverage}}%</td><td class="num">{{printf "%.1f" .CRAP}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Failures}}
+<h2>Failed packages</h2>
+{{range .Failures}}<pre>{{.}}</pre>
+{{end}}
+{{end}}
+` + pageFooterHTML))
+
+var fileTemplate = template.Must(template.New("file").Parse(pageHeaderHTML + `
+{{.Source}}
+` + pageFooterHTML))
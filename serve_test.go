@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
+)
+
+func testServeFiles() []carpet.FileCover {
+	return []carpet.FileCover{
+		{
+			Profile: &cover.Profile{FileName: "pkg/a.go", Mode: "count", Blocks: []cover.ProfileBlock{
+				{StartLine: 3, StartCol: 15, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 2},
+				{StartLine: 5, StartCol: 2, EndLine: 5, EndCol: 9, NumStmt: 1, Count: 0},
+			}},
+			Content: []byte("package pkg\n\nfunc a() bool {\n\tif 1 < 2 {\n\t}\n}\n"),
+		},
+		{
+			Profile: &cover.Profile{FileName: "cmd/b.go", Mode: "count", Blocks: []cover.ProfileBlock{
+				{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 5, NumStmt: 1, Count: 0},
+			}},
+			Content: []byte("package main\n"),
+		},
+	}
+}
+
+func Test_htmlRenderer(t *testing.T) {
+	file := carpet.FileCover{
+		Profile: &cover.Profile{FileName: "a.go", Mode: "count", Blocks: []cover.ProfileBlock{
+			{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 7, NumStmt: 1, Count: 3},
+			{StartLine: 3, StartCol: 1, EndLine: 3, EndCol: 6, NumStmt: 1, Count: 0},
+		}},
+		Content: []byte("a < b\nx && y\nz > 0\n"),
+	}
+
+	buf := &bytes.Buffer{}
+	if err := carpet.RenderFiles(buf, &htmlRenderer{}, []carpet.FileCover{file}, carpet.RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<pre>a &lt; b` + "\n" +
+		`<span class="cov" title="3">x &amp;&amp; y</span>` + "\n" +
+		`<span class="uncov" title="0">z &gt; 0</span>` + "\n" +
+		"</pre>\n"
+	if buf.String() != want {
+		t.Errorf("htmlRenderer:\ngot : %q\nwant: %q", buf.String(), want)
+	}
+}
+
+func Test_getPackagesTree(t *testing.T) {
+	tree := getPackagesTree(testServeFiles())
+	if len(tree) != 2 || tree[0].Name != "cmd" || tree[1].Name != "pkg" || tree[1].Files[0].FileName != "pkg/a.go" {
+		t.Errorf("getPackagesTree() = %+v", tree)
+	}
+}
+
+func Test_coverServer(t *testing.T) {
+	srv := newCoverServer(Config{serve: ":8080"}, nil, "", nil)
+	srv.run.files = testServeFiles()
+	handler := srv.handler()
+
+	tests := []struct {
+		method, url string
+		host        string
+		headers     map[string]string
+		code        int
+		contains    []string
+	}{
+		{method: http.MethodGet, url: "/", code: http.StatusOK, contains: []string{`href="/file?name=pkg%2fa.go"`, "<h3>cmd</h3>", "<td>a</td>", "Coverage: 33.3% of statements"}},
+		{method: http.MethodGet, url: "/file?name=pkg/a.go", code: http.StatusOK, contains: []string{"pkg/a.go - 50.0%", `<span class="uncov" title="0">`}},
+		{method: http.MethodGet, url: "/file?name=not-exists.go", code: http.StatusNotFound},
+		{method: http.MethodGet, url: "/not-exists", code: http.StatusNotFound},
+		{method: http.MethodGet, url: "/run", code: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, url: "/run", headers: map[string]string{"Origin": "http://evil.example"}, code: http.StatusForbidden},
+		{method: http.MethodPost, url: "/run", headers: map[string]string{"Sec-Fetch-Site": "cross-site"}, code: http.StatusForbidden},
+		{method: http.MethodPost, url: "/run", headers: map[string]string{"Origin": "http://localhost:8080", "Sec-Fetch-Site": "same-origin"}, code: http.StatusAccepted},
+		{method: http.MethodGet, url: "/file?name=pkg/a.go", host: "evil.example:8080", code: http.StatusForbidden},
+		{method: http.MethodPost, url: "/run", host: "evil.example:8080", headers: map[string]string{"Origin": "http://evil.example:8080", "Sec-Fetch-Site": "same-origin"}, code: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.url, nil)
+		req.Host = "localhost:8080"
+		if tt.host != "" {
+			req.Host = tt.host
+		}
+		for name, value := range tt.headers {
+			req.Header.Set(name, value)
+		}
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("%s %s: got code %d, want %d", tt.method, tt.url, rec.Code, tt.code)
+		}
+		for _, str := range tt.contains {
+			if !strings.Contains(rec.Body.String(), str) {
+				t.Errorf("%s %s: body does not contain %q", tt.method, tt.url, str)
+			}
+		}
+	}
+}
+
+func Test_getListenAddress(t *testing.T) {
+	tests := []struct{ address, want string }{
+		{address: ":8080", want: "127.0.0.1:8080"},
+		{address: "0.0.0.0:8080", want: "0.0.0.0:8080"},
+		{address: "localhost:0", want: "localhost:0"},
+		{address: "[::1]:8080", want: "[::1]:8080"},
+	}
+
+	for _, tt := range tests {
+		if got := getListenAddress(tt.address); got != tt.want {
+			t.Errorf("getListenAddress(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
+
+func Test_isAllowedHost(t *testing.T) {
+	tests := []struct {
+		listenHost, requestHost string
+		want                    bool
+	}{
+		{listenHost: "127.0.0.1", requestHost: "localhost:8080", want: true},
+		{listenHost: "127.0.0.1", requestHost: "127.0.0.1:8080", want: true},
+		{listenHost: "127.0.0.1", requestHost: "[::1]:8080", want: true},
+		{listenHost: "localhost", requestHost: "localhost", want: true},
+		{listenHost: "::1", requestHost: "[::1]", want: true},
+		{listenHost: "127.0.0.1", requestHost: "evil.example:8080", want: false},
+		{listenHost: "localhost", requestHost: "evil.example", want: false},
+		{listenHost: "0.0.0.0", requestHost: "host.example:8080", want: true},
+		{listenHost: "", requestHost: "host.example:8080", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := isAllowedHost(tt.listenHost, tt.requestHost); got != tt.want {
+			t.Errorf("isAllowedHost(%q, %q) = %v, want %v", tt.listenHost, tt.requestHost, got, tt.want)
+		}
+	}
+}
+
+func Test_getSourcesState(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(fileName, []byte("package a\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	state := getSourcesState([]string{dir})
+	if state != getSourcesState([]string{dir}) {
+		t.Errorf("getSourcesState() is changed without changes of files")
+	}
+
+	if err := os.WriteFile(fileName, []byte("package a\n\nvar x int\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if state == getSourcesState([]string{dir}) {
+		t.Errorf("getSourcesState() is not changed after change of file")
+	}
+}
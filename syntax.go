@@ -0,0 +1,165 @@
+package main
+
+import (
+	"go/scanner"
+	"go/token"
+
+	"github.com/mgutz/ansi"
+	"github.com/msoap/go-carpet/carpet"
+)
+
+type syntaxClass int
+
+const (
+	syntaxNone syntaxClass = iota
+	syntaxKeyword
+	syntaxString
+	syntaxComment
+	syntaxNumber
+)
+
+// getSyntaxClasses - get syntax class of each byte of golang source
+func getSyntaxClasses(fileBytes []byte) []syntaxClass {
+	result := make([]syntaxClass, len(fileBytes))
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(fileBytes))
+	var goScanner scanner.Scanner
+	// errors are ignored, it's ok to highlight only part of broken source
+	goScanner.Init(file, fileBytes, nil, scanner.ScanComments)
+
+	for {
+		pos, tok, lit := goScanner.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		class := syntaxNone
+		switch {
+		case tok.IsKeyword():
+			class = syntaxKeyword
+		case tok == token.STRING || tok == token.CHAR:
+			class = syntaxString
+		case tok == token.COMMENT:
+			class = syntaxComment
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = syntaxNumber
+		}
+		if class == syntaxNone {
+			continue
+		}
+
+		length := len(lit)
+		if length == 0 {
+			length = len(tok.String())
+		}
+
+		for offset := file.Offset(pos); offset < file.Offset(pos)+length && offset < len(result); offset++ {
+			result[offset] = class
+		}
+	}
+
+	return result
+}
+
+// getSyntaxCoverStyle - syntax color in foreground and coverage in background, empty string for default style
+func getSyntaxCoverStyle(class syntaxClass, state carpet.CoverState, th theme, config Config) string {
+	background := ""
+	switch state {
+	case carpet.CoverCovered:
+		background = th.CoveredBg.get(config.colors256, config.trueColor)
+	case carpet.CoverUncovered:
+		background = th.UncoveredBg.get(config.colors256, config.trueColor)
+	}
+
+	foreground := ""
+	switch class {
+	case syntaxKeyword:
+		foreground = th.Keyword.get(config.colors256, config.trueColor)
+	case syntaxString:
+		foreground = th.String.get(config.colors256, config.trueColor)
+	case syntaxComment:
+		foreground = th.Comment.get(config.colors256, config.trueColor)
+	case syntaxNumber:
+		foreground = th.Number.get(config.colors256, config.trueColor)
+	}
+	if foreground == "" && background == "" {
+		return ""
+	}
+	if foreground == "" {
+		foreground = "default"
+	}
+	if background == "" {
+		return foreground
+	}
+
+	return foreground + ":" + background
+}
+
+// syntaxHighlighter - highlights source by syntax and shows coverage by background color
+type syntaxHighlighter struct {
+	classes   []syntaxClass
+	th        theme
+	config    Config
+	curStyle  string
+	lineBegin bool
+}
+
+func newSyntaxHighlighter(fileBytes []byte, th theme, config Config) syntaxHighlighter {
+	return syntaxHighlighter{classes: getSyntaxClasses(fileBytes), th: th, config: config}
+}
+
+func (highlighter *syntaxHighlighter) beginRange() {
+	highlighter.curStyle, highlighter.lineBegin = "", true
+}
+
+// endRange - reset color in end of range
+func (highlighter *syntaxHighlighter) endRange() (result []byte) {
+	if highlighter.curStyle != "" {
+		result = append(result, ansi.ColorCode("reset")...)
+	}
+
+	return append(result, '\n')
+}
+
+// segment - get highlighted segment of source
+func (highlighter *syntaxHighlighter) segment(segment carpet.Segment) (result []byte) {
+	reset := []byte(ansi.ColorCode("reset"))
+
+	for i, char := range segment.Text {
+		if char == '\n' {
+			// reset color in end of each line (this fixed view in "less -R")
+			if highlighter.curStyle != "" {
+				result = append(result, reset...)
+				highlighter.curStyle = ""
+			}
+			result = append(result, char)
+			highlighter.lineBegin = true
+			continue
+		}
+
+		state := segment.State
+		if highlighter.lineBegin && (char == ' ' || char == '\t') {
+			// don't fill indentation with background
+			state = carpet.CoverNone
+		} else {
+			highlighter.lineBegin = false
+		}
+
+		class := syntaxNone
+		if offset := segment.Offset + i; offset < len(highlighter.classes) {
+			class = highlighter.classes[offset]
+		}
+		if style := getSyntaxCoverStyle(class, state, highlighter.th, highlighter.config); style != highlighter.curStyle {
+			if style == "" {
+				result = append(result, reset...)
+			} else {
+				result = append(result, []byte(colorCode(style))...)
+			}
+			highlighter.curStyle = style
+		}
+		result = append(result, char)
+	}
+
+	return result
+}
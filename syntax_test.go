@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mgutz/ansi"
+	"golang.org/x/tools/cover"
+)
+
+func Test_getSyntaxClasses(t *testing.T) {
+	src := []byte("if x == 10 { // c\n\ts := \"a\"\n}")
+	got := getSyntaxClasses(src)
+
+	K, S, C, N, o := syntaxKeyword, syntaxString, syntaxComment, syntaxNumber, syntaxNone
+	want := []syntaxClass{
+		K, K, o, o, o, o, o, o, N, N, o, o, o, C, C, C, C, o,
+		o, o, o, o, o, o, S, S, S, o,
+		o,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getSyntaxClasses():\ngot : %v\nwant: %v", got, want)
+	}
+}
+
+func Test_renderFilesCover_syntax(t *testing.T) {
+	fileProfile := &cover.Profile{
+		FileName: "filename.go",
+		Mode:     "count",
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 2, StartCol: 2, EndLine: 2, EndCol: 8, NumStmt: 1, Count: 1},
+			{StartLine: 3, StartCol: 2, EndLine: 3, EndCol: 6, NumStmt: 1, Count: 0},
+		},
+	}
+	fileContent := []byte("{\n\tgo f()\n\tg(1)\n}")
+
+	got := renderFileCover(fileProfile, fileContent, Config{syntax: true})
+	want := getColorHeader("filename.go - 50.0%", true, Config{}) +
+		"{\n" +
+		"\t" + ansi.ColorCode("magenta+h:green") + "go" + ansi.ColorCode("default:green") + " f()" + ansi.ColorCode("reset") + "\n" +
+		"\t" + ansi.ColorCode("default:red") + "g(" + ansi.ColorCode("cyan+h:red") + "1" + ansi.ColorCode("default:red") + ")" + ansi.ColorCode("reset") + "\n" +
+		"}\n"
+	if string(got) != want {
+		t.Errorf("renderFilesCover() with syntax:\ngot : %q\nwant: %q", got, want)
+	}
+
+	got = renderFileCover(fileProfile, fileContent, Config{syntax: true, colors256: true})
+	want = getColorHeader("filename.go - 50.0%", true, Config{}) +
+		"{\n" +
+		"\t" + ansi.ColorCode("magenta+h:22") + "go" + ansi.ColorCode("default:22") + " f()" + ansi.ColorCode("reset") + "\n" +
+		"\t" + ansi.ColorCode("default:52") + "g(" + ansi.ColorCode("cyan+h:52") + "1" + ansi.ColorCode("default:52") + ")" + ansi.ColorCode("reset") + "\n" +
+		"}\n"
+	if string(got) != want {
+		t.Errorf("renderFilesCover() with syntax and 256 colors:\ngot : %q\nwant: %q", got, want)
+	}
+}
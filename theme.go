@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mgutz/ansi"
+)
+
+const defaultThemeName = "dark"
+
+// themeColor - one color of theme for terminals with 16 colors, 256 colors and truecolor,
+// in "foreground+attributes:background+attributes" format of github.com/mgutz/ansi, truecolor as "#rrggbb"
+type themeColor struct {
+	Basic string `json:"basic"`
+	C256  string `json:"256"`
+	RGB   string `json:"rgb"`
+}
+
+// UnmarshalJSON - color in theme file may be a string with basic color or object with all variants
+func (color *themeColor) UnmarshalJSON(data []byte) error {
+	var basic string
+	if err := json.Unmarshal(data, &basic); err == nil {
+		*color = themeColor{Basic: basic}
+		return nil
+	}
+
+	type plainThemeColor themeColor
+	result := plainThemeColor{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	*color = themeColor(result)
+
+	return nil
+}
+
+// get - get the best variant of color for terminal
+func (color themeColor) get(colors256, trueColor bool) string {
+	switch {
+	case trueColor && color.RGB != "":
+		return color.RGB
+	case colors256 && color.C256 != "":
+		return color.C256
+	default:
+		return color.Basic
+	}
+}
+
+// theme - colors for coverage output
+type theme struct {
+	Header    themeColor `json:"header"`
+	Underline themeColor `json:"underline"`
+	Covered   themeColor `json:"covered"`
+	Uncovered themeColor `json:"uncovered"`
+	// 256-colors shades of covered code, from rarely to often executed code
+	Shades []string `json:"shades"`
+	// two "#rrggbb" colors for shades of covered code on truecolor terminal
+	Gradient []string `json:"gradient"`
+	// backgrounds and syntax colors for -syntax option
+	CoveredBg   themeColor `json:"covered_bg"`
+	UncoveredBg themeColor `json:"uncovered_bg"`
+	Keyword     themeColor `json:"keyword"`
+	String      themeColor `json:"string"`
+	Comment     themeColor `json:"comment"`
+	Number      themeColor `json:"number"`
+}
+
+var themes = map[string]theme{
+	"dark": {
+		Header:      themeColor{Basic: "yellow"},
+		Underline:   themeColor{Basic: "black+h"},
+		Covered:     themeColor{Basic: "green"},
+		Uncovered:   themeColor{Basic: "red"},
+		Shades:      tenShadesOfGreen[:],
+		Gradient:    []string{"#00875f", "#00ffff"},
+		CoveredBg:   themeColor{Basic: "green", C256: "22"},
+		UncoveredBg: themeColor{Basic: "red", C256: "52"},
+		Keyword:     themeColor{Basic: "magenta+h"},
+		String:      themeColor{Basic: "yellow+h"},
+		Comment:     themeColor{Basic: "black+h"},
+		Number:      themeColor{Basic: "cyan+h"},
+	},
+	"light": {
+		Header:      themeColor{Basic: "blue"},
+		Underline:   themeColor{Basic: "black"},
+		Covered:     themeColor{Basic: "green", C256: "28"},
+		Uncovered:   themeColor{Basic: "red", C256: "160"},
+		Shades:      []string{"114", "78", "71", "70", "34", "34", "28", "28", "22", "22"},
+		Gradient:    []string{"#5faf5f", "#005f00"},
+		CoveredBg:   themeColor{Basic: "green", C256: "194"},
+		UncoveredBg: themeColor{Basic: "red", C256: "224"},
+		Keyword:     themeColor{Basic: "blue"},
+		String:      themeColor{Basic: "magenta"},
+		Comment:     themeColor{Basic: "black+h"},
+		Number:      themeColor{Basic: "cyan"},
+	},
+	"high-contrast": {
+		Header:      themeColor{Basic: "white+bh"},
+		Underline:   themeColor{Basic: "white+h"},
+		Covered:     themeColor{Basic: "green+bh"},
+		Uncovered:   themeColor{Basic: "white+bh:red"},
+		Shades:      tenShadesOfGreen[:],
+		Gradient:    []string{"#00d700", "#00ffff"},
+		CoveredBg:   themeColor{Basic: "green", C256: "28"},
+		UncoveredBg: themeColor{Basic: "red", C256: "160"},
+		Keyword:     themeColor{Basic: "white+bh"},
+		String:      themeColor{Basic: "yellow+bh"},
+		Comment:     themeColor{Basic: "white"},
+		Number:      themeColor{Basic: "cyan+bh"},
+	},
+	// blue/orange palette from Okabe & Ito "Color Universal Design"
+	"colorblind": {
+		Header:      themeColor{Basic: "white+h"},
+		Underline:   themeColor{Basic: "black+h"},
+		Covered:     themeColor{Basic: "blue+h", C256: "33", RGB: "#56b4e9"},
+		Uncovered:   themeColor{Basic: "yellow", C256: "208", RGB: "#e69f00"},
+		Shades:      []string{"24", "25", "26", "27", "32", "33", "38", "39", "45", "51"},
+		Gradient:    []string{"#0072b2", "#56b4e9"},
+		CoveredBg:   themeColor{Basic: "blue", C256: "24", RGB: "#0072b2"},
+		UncoveredBg: themeColor{Basic: "yellow", C256: "130", RGB: "#d55e00"},
+		Keyword:     themeColor{Basic: "white+bh"},
+		String:      themeColor{Basic: "cyan+h"},
+		Comment:     themeColor{Basic: "black+h"},
+		Number:      themeColor{Basic: "magenta+h"},
+	},
+}
+
+func getThemeNames() []string {
+	result := make([]string, 0, len(themes))
+	for name := range themes {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// loadTheme - get built-in theme by name or load user-defined theme from JSON file,
+// user-defined theme may be based on built-in theme: {"base": "light", "uncovered": "red+b"}
+func loadTheme(nameOrFile string) (theme, error) {
+	if result, ok := themes[nameOrFile]; ok {
+		return result, nil
+	}
+
+	content, err := os.ReadFile(nameOrFile) // #nosec
+	if err != nil {
+		return theme{}, fmt.Errorf("theme %q is not one of: %s, and failed to read theme file: %s", nameOrFile, strings.Join(getThemeNames(), ", "), err)
+	}
+
+	base := struct {
+		Base string `json:"base"`
+	}{Base: defaultThemeName}
+	if err := json.Unmarshal(content, &base); err != nil {
+		return theme{}, fmt.Errorf("failed to parse theme file %s: %s", nameOrFile, err)
+	}
+
+	result, ok := themes[base.Base]
+	if !ok {
+		return theme{}, fmt.Errorf("theme file %s: unknown base theme %q", nameOrFile, base.Base)
+	}
+	if err := json.Unmarshal(content, &result); err != nil {
+		return theme{}, fmt.Errorf("failed to parse theme file %s: %s", nameOrFile, err)
+	}
+	if len(result.Shades) == 0 {
+		return theme{}, fmt.Errorf("theme file %s: shades is empty", nameOrFile)
+	}
+	if len(result.Gradient) != 0 && len(result.Gradient) != 2 {
+		return theme{}, fmt.Errorf("theme file %s: gradient must have two colors", nameOrFile)
+	}
+
+	return result, nil
+}
+
+// isTrueColorTerminal - terminal supports 24-bit colors
+func isTrueColorTerminal() bool {
+	colorTerm := os.Getenv("COLORTERM")
+	return colorTerm == "truecolor" || colorTerm == "24bit"
+}
+
+// getCoveredColor - get color of covered code, with -256colors option shade depends on execution count,
+// in "set" cover mode execution counts are unknown and shades are not used
+func (th theme) getCoveredColor(normCover float64, coverMode string, config Config) string {
+	if !config.colors256 {
+		return colorCode(th.Covered.get(false, config.trueColor))
+	}
+	if coverMode == coverModeSet {
+		return colorCode(th.Covered.get(true, config.trueColor))
+	}
+
+	if config.trueColor && len(th.Gradient) == 2 {
+		if color, err := getGradientColor(th.Gradient[0], th.Gradient[1], normCover); err == nil {
+			return colorCode(color)
+		}
+	}
+
+	return colorCode(getShade(th.Shades, normCover))
+}
+
+// colorCode - get escape sequence for color, like ansi.ColorCode() but with support of "#rrggbb" truecolor
+func colorCode(style string) string {
+	if !strings.Contains(style, "#") {
+		return ansi.ColorCode(style)
+	}
+
+	foreground, background := style, ""
+	if i := strings.Index(style, ":"); i >= 0 {
+		foreground, background = style[:i], style[i+1:]
+	}
+
+	params := []string{"0"}
+	switch {
+	case strings.HasPrefix(foreground, "#"):
+		rgb, err := parseRGB(foreground)
+		if err != nil {
+			return ""
+		}
+		params = append(params, "38;2;"+rgb)
+	case foreground != "":
+		params = []string{strings.TrimSuffix(strings.TrimPrefix(ansi.ColorCode(foreground), "\033["), "m")}
+	}
+
+	switch {
+	case strings.HasPrefix(background, "#"):
+		rgb, err := parseRGB(background)
+		if err != nil {
+			return ""
+		}
+		params = append(params, "48;2;"+rgb)
+	case background != "":
+		// "\033[0;39;41m" -> "41"
+		code := strings.TrimSuffix(strings.TrimPrefix(ansi.ColorCode("default:"+background), "\033[0;39;"), "m")
+		params = append(params, code)
+	}
+
+	return "\033[" + strings.Join(params, ";") + "m"
+}
+
+// parseRGB - "#00ff80" -> "0;255;128"
+func parseRGB(color string) (string, error) {
+	red, green, blue, err := parseHexColor(color)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d;%d;%d", red, green, blue), nil
+}
+
+func parseHexColor(color string) (red, green, blue uint8, err error) {
+	if len(color) != 7 || color[0] != '#' {
+		return 0, 0, 0, fmt.Errorf("color %q is not in #rrggbb format", color)
+	}
+
+	value, err := strconv.ParseUint(color[1:], 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("color %q is not in #rrggbb format", color)
+	}
+
+	return uint8(value >> 16), uint8(value >> 8), uint8(value), nil
+}
+
+// getGradientColor - get color between two colors, normCover in [0, 1]
+func getGradientColor(from, to string, normCover float64) (string, error) {
+	fromRed, fromGreen, fromBlue, err := parseHexColor(from)
+	if err != nil {
+		return "", err
+	}
+	toRed, toGreen, toBlue, err := parseHexColor(to)
+	if err != nil {
+		return "", err
+	}
+
+	if normCover < 0 {
+		normCover = 0
+	}
+	if normCover > 1 {
+		normCover = 1
+	}
+
+	mix := func(from, to uint8) uint8 {
+		return uint8(float64(from) + (float64(to)-float64(from))*normCover + 0.5)
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x", mix(fromRed, toRed), mix(fromGreen, toGreen), mix(fromBlue, toBlue)), nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mgutz/ansi"
+	"golang.org/x/tools/cover"
+)
+
+func Test_themeColor(t *testing.T) {
+	color := themeColor{Basic: "green", C256: "34", RGB: "#00ff00"}
+	if color.get(false, false) != "green" || color.get(true, false) != "34" || color.get(true, true) != "#00ff00" || color.get(false, true) != "#00ff00" {
+		t.Errorf("themeColor.get() failed")
+	}
+	if (themeColor{Basic: "red"}).get(true, true) != "red" {
+		t.Errorf("themeColor.get() failed for basic only color")
+	}
+
+	colors := struct {
+		A themeColor `json:"a"`
+		B themeColor `json:"b"`
+	}{}
+	if err := json.Unmarshal([]byte(`{"a": "red", "b": {"basic": "blue", "256": "33", "rgb": "#0000ff"}}`), &colors); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %s", err)
+	}
+	if colors.A != (themeColor{Basic: "red"}) || colors.B != (themeColor{Basic: "blue", C256: "33", RGB: "#0000ff"}) {
+		t.Errorf("themeColor.UnmarshalJSON() failed: %#v", colors)
+	}
+}
+
+func Test_loadTheme(t *testing.T) {
+	for _, name := range getThemeNames() {
+		if _, err := loadTheme(name); err != nil {
+			t.Errorf("loadTheme(%q) failed: %s", name, err)
+		}
+	}
+
+	dir := t.TempDir()
+	writeTheme := func(name, content string) string {
+		fileName := filepath.Join(dir, name)
+		if err := os.WriteFile(fileName, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return fileName
+	}
+
+	th, err := loadTheme(writeTheme("my.json", `{"base": "light", "uncovered": "red+b", "covered": {"basic": "blue", "rgb": "#0000ff"}}`))
+	if err != nil {
+		t.Fatalf("loadTheme() failed: %s", err)
+	}
+	expect := themes["light"]
+	expect.Uncovered = themeColor{Basic: "red+b"}
+	expect.Covered = themeColor{Basic: "blue", RGB: "#0000ff"}
+	if !reflect.DeepEqual(th, expect) {
+		t.Errorf("loadTheme() got %#v", th)
+	}
+
+	th, err = loadTheme(writeTheme("default.json", `{"header": "blue"}`))
+	if err != nil || th.Header.Basic != "blue" || th.Uncovered.Basic != "red" {
+		t.Errorf("loadTheme() without base failed: %s, %#v", err, th)
+	}
+
+	for _, content := range []string{
+		`{"base": "not-exists"}`,
+		`not json`,
+		`{"shades": []}`,
+		`{"gradient": ["#000000"]}`,
+	} {
+		if _, err := loadTheme(writeTheme("bad.json", content)); err == nil {
+			t.Errorf("loadTheme() not got error for: %s", content)
+		}
+	}
+
+	if _, err := loadTheme(filepath.Join(dir, "not-exists.json")); err == nil {
+		t.Errorf("loadTheme() not got error for not exists file")
+	}
+}
+
+func Test_colorCode(t *testing.T) {
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{style: "green", want: ansi.ColorCode("green")},
+		{style: "red:green", want: ansi.ColorCode("red:green")},
+		{style: "#00ff80", want: "\033[0;38;2;0;255;128m"},
+		{style: "magenta+h:#102030", want: "\033[0;95;48;2;16;32;48m"},
+		{style: "#ffffff:red", want: "\033[0;38;2;255;255;255;41m"},
+		{style: "#xyz", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			if got := colorCode(tt.style); got != tt.want {
+				t.Errorf("colorCode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getGradientColor(t *testing.T) {
+	tests := []struct {
+		norm float64
+		want string
+	}{
+		{norm: 0, want: "#000000"},
+		{norm: 0.5, want: "#808080"},
+		{norm: 1, want: "#ffffff"},
+		{norm: -1, want: "#000000"},
+		{norm: 100, want: "#ffffff"},
+	}
+
+	for _, tt := range tests {
+		got, err := getGradientColor("#000000", "#ffffff", tt.norm)
+		if err != nil || got != tt.want {
+			t.Errorf("getGradientColor(%v) = %q, %v, want %q", tt.norm, got, err, tt.want)
+		}
+	}
+
+	if _, err := getGradientColor("#000000", "white", 0.5); err == nil {
+		t.Errorf("getGradientColor() not got error")
+	}
+}
+
+func Test_renderFilesCover_theme(t *testing.T) {
+	fileProfile := &cover.Profile{
+		FileName: "filename.go",
+		Mode:     "count",
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 2, StartCol: 3, EndLine: 2, EndCol: 8, NumStmt: 1, Count: 1},
+			{StartLine: 3, StartCol: 3, EndLine: 3, EndCol: 6, NumStmt: 1, Count: 0},
+		},
+	}
+	fileContent := []byte("package x\n2 green\n3 red\n")
+
+	th := themes["colorblind"]
+	config := Config{theme: &th}
+	got := renderFileCover(fileProfile, fileContent, config)
+	want := getColorHeader("filename.go - 50.0%", true, config) +
+		"package x\n" +
+		"2 " + ansi.ColorCode("blue+h") + "green" + ansi.ColorCode("reset") + "\n" +
+		"3 " + ansi.ColorCode("yellow") + "red" + ansi.ColorCode("reset") + "\n\n"
+	if string(got) != want {
+		t.Errorf("1. renderFilesCover() with theme:\ngot : %q\nwant: %q", got, want)
+	}
+
+	config = Config{theme: &th, colors256: true, trueColor: true}
+	got = renderFileCover(fileProfile, fileContent, config)
+	want = getColorHeader("filename.go - 50.0%", true, config) +
+		"package x\n" +
+		"2 \033[0;38;2;69;167;222m" + "green" + ansi.ColorCode("reset") + "\n" +
+		"3 \033[0;38;2;230;159;0m" + "red" + ansi.ColorCode("reset") + "\n\n"
+	if string(got) != want {
+		t.Errorf("2. renderFilesCover() with theme and truecolor:\ngot : %q\nwant: %q", got, want)
+	}
+
+	fileProfile.Mode = "set"
+	config = Config{colors256: true}
+	got = renderFileCover(fileProfile, fileContent, config)
+	want = getColorHeader("filename.go - 50.0%", true, config) +
+		"package x\n" +
+		"2 " + ansi.ColorCode("green") + "green" + ansi.ColorCode("reset") + "\n" +
+		"3 " + ansi.ColorCode("red") + "red" + ansi.ColorCode("reset") + "\n\n"
+	if string(got) != want {
+		t.Errorf("3. renderFilesCover() in set mode:\ngot : %q\nwant: %q", got, want)
+	}
+}
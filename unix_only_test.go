@@ -4,83 +4,98 @@
 package main
 
 import (
+	"os"
 	"reflect"
 	"testing"
+
+	"github.com/msoap/go-carpet/carpet"
+	"golang.org/x/tools/cover"
 )
 
-func Test_getCoverForDir(t *testing.T) {
+// loadAndRenderCover - get colored source of files from cover profile
+func loadAndRenderCover(coverFileName string, filesFilter []string, config Config) ([]byte, []cover.ProfileBlock, error) {
+	files, err := carpet.LoadFiles(coverFileName, carpet.Filter{Files: filesFilter, MinCoverage: config.minCoverage})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, profileBlocks := renderFilesCover(files, config)
+	return result, profileBlocks, nil
+}
+
+func Test_renderFilesCover_profile(t *testing.T) {
 	t.Run("error", func(t *testing.T) {
-		_, _, err := getCoverForDir("./testdata/not_exists.out", []string{}, Config{colors256: false})
+		_, _, err := loadAndRenderCover("./testdata/not_exists.out", []string{}, Config{colors256: false})
 		if err == nil {
-			t.Errorf("1. getCoverForDir() error failed")
+			t.Errorf("1. renderFilesCover() error failed")
 		}
 	})
 
 	t.Run("cover", func(t *testing.T) {
-		bytes, _, err := getCoverForDir("./testdata/cover_00.out", []string{}, Config{colors256: false})
+		bytes, _, err := loadAndRenderCover("./testdata/cover_00.out", []string{}, Config{colors256: false})
 		if err != nil {
-			t.Errorf("2. getCoverForDir() failed: %v", err)
+			t.Errorf("2. renderFilesCover() failed: %v", err)
 		}
-		expect, err := readFile("./testdata/colored_00.txt")
+		expect, err := os.ReadFile("./testdata/colored_00.txt")
 		if err != nil {
-			t.Errorf("3. getCoverForDir() failed: %v", err)
+			t.Errorf("3. renderFilesCover() failed: %v", err)
 		}
 		if !reflect.DeepEqual(bytes, expect) {
-			t.Errorf("4. getCoverForDir() not equal")
+			t.Errorf("4. renderFilesCover() not equal")
 		}
 	})
 
 	t.Run("cover with 256 colors", func(t *testing.T) {
-		bytes, _, err := getCoverForDir("./testdata/cover_00.out", []string{}, Config{colors256: true})
+		bytes, _, err := loadAndRenderCover("./testdata/cover_00.out", []string{}, Config{colors256: true})
 		if err != nil {
-			t.Errorf("5. getCoverForDir() failed: %v", err)
+			t.Errorf("5. renderFilesCover() failed: %v", err)
 		}
-		expect, err := readFile("./testdata/colored_01.txt")
+		expect, err := os.ReadFile("./testdata/colored_01.txt")
 		if err != nil {
-			t.Errorf("6. getCoverForDir() failed: %v", err)
+			t.Errorf("6. renderFilesCover() failed: %v", err)
 		}
 		if !reflect.DeepEqual(bytes, expect) {
-			t.Errorf("7. getCoverForDir() not equal")
+			t.Errorf("7. renderFilesCover() not equal")
 		}
 	})
 
 	t.Run("cover with 256 colors with error", func(t *testing.T) {
-		_, _, err := getCoverForDir("./testdata/cover_01.out", []string{}, Config{colors256: true})
+		_, _, err := loadAndRenderCover("./testdata/cover_01.out", []string{}, Config{colors256: true})
 		if err == nil {
-			t.Errorf("8. getCoverForDir() not exists go file")
+			t.Errorf("8. renderFilesCover() not exists go file")
 		}
 	})
 
 	t.Run("cover 01 without 256 colors", func(t *testing.T) {
-		bytes, _, err := getCoverForDir("./testdata/cover_00.out", []string{"file_01.go"}, Config{colors256: false})
+		bytes, _, err := loadAndRenderCover("./testdata/cover_00.out", []string{"file_01.go"}, Config{colors256: false})
 		if err != nil {
-			t.Errorf("9. getCoverForDir() failed: %v", err)
+			t.Errorf("9. renderFilesCover() failed: %v", err)
 		}
-		expect, err := readFile("./testdata/colored_02.txt")
+		expect, err := os.ReadFile("./testdata/colored_02.txt")
 		if err != nil {
-			t.Errorf("10. getCoverForDir() failed: %v", err)
+			t.Errorf("10. renderFilesCover() failed: %v", err)
 		}
 		if !reflect.DeepEqual(bytes, expect) {
-			t.Errorf("11. getCoverForDir() not equal")
+			t.Errorf("11. renderFilesCover() not equal")
 		}
 	})
 
 	t.Run("cover 02 without 256 colors", func(t *testing.T) {
-		bytes, _, err := getCoverForDir("./testdata/cover_02.out", []string{}, Config{colors256: false})
+		bytes, _, err := loadAndRenderCover("./testdata/cover_02.out", []string{}, Config{colors256: false})
 		if err != nil {
-			t.Errorf("12. getCoverForDir() failed: %v", err)
+			t.Errorf("12. renderFilesCover() failed: %v", err)
 		}
-		expect, err := readFile("./testdata/colored_03.txt")
+		expect, err := os.ReadFile("./testdata/colored_03.txt")
 		if err != nil {
-			t.Errorf("13. getCoverForDir() failed: %v", err)
+			t.Errorf("13. renderFilesCover() failed: %v", err)
 		}
 		if !reflect.DeepEqual(bytes, expect) {
-			t.Errorf("14. getCoverForDir() not equal\ngot:\n%s\nexpect:\n%s\n", bytes, expect)
+			t.Errorf("14. renderFilesCover() not equal\ngot:\n%s\nexpect:\n%s\n", bytes, expect)
 		}
 	})
 }
 
-func Test_getCoverForDir_mincov_flag(t *testing.T) {
+func Test_renderFilesCover_mincov_flag(t *testing.T) {
 	t.Run("covered 100% mincov 100%", func(t *testing.T) {
 		conf := Config{
 			colors256:   false,
@@ -88,9 +103,9 @@ func Test_getCoverForDir_mincov_flag(t *testing.T) {
 		}
 
 		// cover_00.out has 100% coverage of 2 files
-		_, profileBlocks, err := getCoverForDir("./testdata/cover_00.out", []string{"file_01.go"}, conf)
+		_, profileBlocks, err := loadAndRenderCover("./testdata/cover_00.out", []string{"file_01.go"}, conf)
 		if err != nil {
-			t.Errorf("getCoverForDir() failed with error: %s", err)
+			t.Errorf("renderFilesCover() failed with error: %s", err)
 		}
 
 		expectLen := 2
@@ -108,9 +123,9 @@ func Test_getCoverForDir_mincov_flag(t *testing.T) {
 		}
 
 		// cover_00.out has 100% coverage of 2 files
-		_, profileBlocks, err := getCoverForDir("./testdata/cover_00.out", []string{"file_01.go"}, conf)
+		_, profileBlocks, err := loadAndRenderCover("./testdata/cover_00.out", []string{"file_01.go"}, conf)
 		if err != nil {
-			t.Errorf("getCoverForDir() failed with error: %s", err)
+			t.Errorf("renderFilesCover() failed with error: %s", err)
 		}
 
 		expectLen := 0
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 
 	shellwords "github.com/mattn/go-shellwords"
@@ -61,3 +62,55 @@ func parseAdditionalArgs(argsRaw string, excludes []string) (resultArgs []string
 
 	return resultArgs, nil
 }
+
+// getArgName - name and value of argument in "-name", "--name" or "-name=value" form
+func getArgName(arg string) (name string, value string, hasValue bool) {
+	if strings.HasPrefix(arg, "--") {
+		arg = arg[1:]
+	}
+	if i := strings.Index(arg, "="); i >= 0 {
+		return arg[:i], arg[i+1:], true
+	}
+
+	return arg, "", false
+}
+
+// isArgSet - argument with name (like "-covermode") is in args with or without value
+func isArgSet(name string, args []string) bool {
+	for _, arg := range args {
+		if argName, _, _ := getArgName(arg); argName == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isBoolArgSet - boolean argument with name (like "-race") is set in args: "-race", "-race=true", the last one wins
+func isBoolArgSet(name string, args []string) (result bool) {
+	for _, arg := range args {
+		argName, value, hasValue := getArgName(arg)
+		if argName != name {
+			continue
+		}
+		if !hasValue {
+			result = true
+			continue
+		}
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			result = boolValue
+		}
+	}
+
+	return result
+}
+
+// isStringInSlice - string is equal to one of the elements of the slice
+func isStringInSlice(src string, slice []string) bool {
+	for _, item := range slice {
+		if item == src {
+			return true
+		}
+	}
+	return false
+}